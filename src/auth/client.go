@@ -2,42 +2,87 @@ package main
 
 import (
 	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
 	"log"
+	"os"
+	"time"
 
+	"github.com/golang-jwt/jwt/v5"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
 
+	"github.com/jergoo/go-grpc-tutorial/jwtauth"
+	"github.com/jergoo/go-grpc-tutorial/mtls"
 	pb "github.com/jergoo/go-grpc-tutorial/protos/ping" // 引入编译生成的包
 )
 
 // Ping 单次请求-响应模式
-func Ping() {
-	// 读取服务端证书，并制定对应服务名
-	creds, err := credentials.NewClientTLSFromFile("keys/server.crt", "grpc.server")
+func Ping(target string, opts ...grpc.DialOption) error {
+	// 读取客户端证书和用于验证服务端证书的 CA 证书，并指定对应服务名
+	tlsConfig, err := mtls.ClientTLSConfig("keys/client.crt", "keys/client.key", "keys/ca.crt", "grpc.server")
 	if err != nil {
-		log.Fatalf("load crt fail: %v", err)
+		return fmt.Errorf("load crt fail: %w", err)
+	}
+
+	// 签发 token
+	token, err := signToken("tutorial-client", time.Hour)
+	if err != nil {
+		return fmt.Errorf("sign token fail: %w", err)
 	}
 
 	// 连接配置
-	opts := []grpc.DialOption{
-		// TLS 认证
-		grpc.WithTransportCredentials(creds),
+	opts = append([]grpc.DialOption{
+		// mTLS 认证
+		grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)),
 		// Token 认证
-		grpc.WithPerRPCCredentials(CustomAuth{Token: "1234567890"}),
-	}
-	conn, err := grpc.Dial("localhost:1234", opts...)
+		grpc.WithPerRPCCredentials(CustomAuth{Token: token}),
+	}, opts...)
+	conn, err := grpc.Dial(target, opts...)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 	defer conn.Close()
 
 	// 实例化客户端并调用
 	client := pb.NewPingPongClient(conn)
-	res, err := client.Ping(context.Background(), &pb.PingRequest{Value: "ping"})
+	var header metadata.MD
+	res, err := client.Ping(context.Background(), &pb.PingRequest{Value: "ping"}, grpc.Header(&header))
 	if err != nil {
-		log.Fatal(err)
+		return err
+	}
+	log.Printf("%s, peer: %v, subject: %v", res.Value, header.Get("peer"), header.Get("subject"))
+	return nil
+}
+
+// signToken 使用 keys/jwt.key 签发 ES256 token，服务端使用 keys/jwks.json 中对应的公钥验证
+func signToken(subject string, ttl time.Duration) (string, error) {
+	data, err := os.ReadFile("keys/jwt.key")
+	if err != nil {
+		return "", err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return "", errors.New("invalid pem")
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	claims := jwt.RegisteredClaims{
+		Subject:   subject,
+		Issuer:    issuer,
+		Audience:  jwt.ClaimStrings{audience},
+		IssuedAt:  jwt.NewNumericDate(now),
+		NotBefore: jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
 	}
-	log.Println(res.Value)
+	return jwtauth.Sign(jwt.SigningMethodES256, key, "tutorial", claims)
 }
 
 // CustomAuth 自定义认证类型
@@ -48,7 +93,7 @@ type CustomAuth struct {
 // GetRequestMetadata 生成认证信息
 func (a CustomAuth) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
 	return map[string]string{
-		"authorization": a.Token,
+		"authorization": "Bearer " + a.Token,
 	}, nil
 }
 
@@ -1,9 +1,61 @@
 package main
 
 import (
+	"context"
+	"io"
 	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/jergoo/go-grpc-tutorial/mtls"
+	"github.com/jergoo/go-grpc-tutorial/pingpong"
+	"github.com/jergoo/go-grpc-tutorial/pingtest"
+	pb "github.com/jergoo/go-grpc-tutorial/protos/ping" // 引入编译生成的包
+	"github.com/jergoo/go-grpc-tutorial/ratelimit"
 )
 
+// testCreds 测试使用的服务端和客户端 TLS 证书
+func testCreds(t *testing.T) (server, client credentials.TransportCredentials) {
+	serverConfig, err := mtls.ServerTLSConfig("keys/server.crt", "keys/server.key", "keys/ca.crt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientConfig, err := mtls.ClientTLSConfig("keys/client.crt", "keys/client.key", "keys/ca.crt", "grpc.server")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return credentials.NewTLS(serverConfig), credentials.NewTLS(clientConfig)
+}
+
+// testInterceptors 与 server.go 相同的拦截器
+func testInterceptors(t *testing.T) []grpc.ServerOption {
+	verifier, err := newVerifier()
+	if err != nil {
+		t.Fatal(err)
+	}
+	limiter, err := newLimiter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return interceptors(verifier, limiter)
+}
+
+// newTestServer 启动开启 TLS 和 token 认证的测试服务
+func newTestServer(t *testing.T, opts ...pingtest.Option) *pingtest.Server {
+	serverCreds, clientCreds := testCreds(t)
+	opts = append([]pingtest.Option{
+		pingtest.WithTLS(serverCreds, clientCreds),
+		pingtest.WithServerOptions(testInterceptors(t)...),
+	}, opts...)
+	return pingtest.NewServer(t, pingpong.NewPingPongServer(pingpong.WithHeaderFunc(responseHeader)), opts...)
+}
+
 func TestPing(t *testing.T) {
 	tests := []struct {
 		name string
@@ -12,7 +64,188 @@ func TestPing(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			Ping()
+			t.Parallel()
+			srv := newTestServer(t)
+			if err := Ping(pingtest.Target, srv.DialOption()); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+// callers 依次调用四种模式，返回最终的错误
+var callers = map[string]func(ctx context.Context, client pb.PingPongClient) error{
+	"Ping": func(ctx context.Context, client pb.PingPongClient) error {
+		_, err := client.Ping(ctx, &pb.PingRequest{Value: "ping"})
+		return err
+	},
+	"MultiPong": func(ctx context.Context, client pb.PingPongClient) error {
+		stream, err := client.MultiPong(ctx, &pb.PingRequest{Value: "ping"})
+		if err != nil {
+			return err
+		}
+		for {
+			if _, err := stream.Recv(); err != nil {
+				if err == io.EOF {
+					return nil
+				}
+				return err
+			}
+		}
+	},
+	"MultiPing": func(ctx context.Context, client pb.PingPongClient) error {
+		stream, err := client.MultiPing(ctx)
+		if err != nil {
+			return err
+		}
+		stream.Send(&pb.PingRequest{Value: "ping"})
+		_, err = stream.CloseAndRecv()
+		return err
+	},
+	"MultiPingPong": func(ctx context.Context, client pb.PingPongClient) error {
+		stream, err := client.MultiPingPong(ctx)
+		if err != nil {
+			return err
+		}
+		stream.Send(&pb.PingRequest{Value: "ping"})
+		stream.CloseSend()
+		for {
+			if _, err := stream.Recv(); err != nil {
+				if err == io.EOF {
+					return nil
+				}
+				return err
+			}
+		}
+	},
+}
+
+func TestAuthenticated(t *testing.T) {
+	token, err := signToken("tutorial-client", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := newTestServer(t, pingtest.WithPerRPCCredentials(CustomAuth{Token: token}))
+
+	for name, call := range callers {
+		call := call
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			if err := call(context.Background(), srv.Client); err != nil {
+				t.Fatal(err)
+			}
 		})
 	}
+
+	// 流请求通过 stream.Context() 获取调用方身份，由 responseHeader 返回
+	stream, err := srv.Client.MultiPong(context.Background(), &pb.PingRequest{Value: "ping"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	header, err := stream.Header()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := header.Get("subject"); len(got) != 1 || got[0] != "tutorial-client" {
+		t.Errorf("subject = %v, want [tutorial-client]", got)
+	}
+	if got := header.Get("peer"); len(got) != 1 || got[0] != "spiffe://go-grpc-tutorial/client" {
+		t.Errorf("peer = %v, want [spiffe://go-grpc-tutorial/client]", got)
+	}
+}
+
+func TestUnauthenticated(t *testing.T) {
+	expired, err := signToken("tutorial-client", -time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{name: "missing"},
+		{name: "invalid", token: "1234567890"},
+		{name: "expired", token: expired},
+	}
+	for _, tt := range tests {
+		var opts []pingtest.Option
+		if tt.token != "" {
+			opts = append(opts, pingtest.WithPerRPCCredentials(CustomAuth{Token: tt.token}))
+		}
+		srv := newTestServer(t, opts...)
+		for name, call := range callers {
+			call := call
+			t.Run(tt.name+"/"+name, func(t *testing.T) {
+				t.Parallel()
+				err := call(context.Background(), srv.Client)
+				if status.Code(err) != codes.Unauthenticated {
+					t.Errorf("code = %v, want %v", status.Code(err), codes.Unauthenticated)
+				}
+			})
+		}
+	}
+}
+
+func TestClientCertificateRequired(t *testing.T) {
+	token, err := signToken("tutorial-client", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 只验证服务端证书，不提供客户端证书
+	clientCreds, err := credentials.NewClientTLSFromFile("keys/ca.crt", "grpc.server")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := newTestServer(t)
+	conn := srv.Dial(t, grpc.WithTransportCredentials(clientCreds), grpc.WithPerRPCCredentials(CustomAuth{Token: token}))
+
+	_, err = pb.NewPingPongClient(conn).Ping(context.Background(), &pb.PingRequest{Value: "ping"})
+	if status.Code(err) != codes.Unavailable {
+		t.Errorf("code = %v, want %v", status.Code(err), codes.Unavailable)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	token, err := signToken("tutorial-client", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := newTestServer(t, pingtest.WithPerRPCCredentials(CustomAuth{Token: token}))
+
+	// 同一 subject 连续调用，超过桶容量后被拒绝
+	for i := 0; i < 40; i++ {
+		var trailer metadata.MD
+		_, err := srv.Client.Ping(context.Background(), &pb.PingRequest{Value: "ping"}, grpc.Trailer(&trailer))
+		if err == nil {
+			continue
+		}
+		if status.Code(err) != codes.ResourceExhausted {
+			t.Fatal(err)
+		}
+		if _, ok := ratelimit.RetryAfter(trailer); !ok {
+			t.Errorf("retry-after missing: %v", trailer)
+		}
+		return
+	}
+	t.Error("rate limit not applied")
+}
+
+func TestHealthWithoutToken(t *testing.T) {
+	serverCreds, clientCreds := testCreds(t)
+	srv := pingpong.NewServer(pingpong.WithGRPCOptions(append([]grpc.ServerOption{grpc.Creds(serverCreds)}, testInterceptors(t)...)...))
+	ts := pingtest.Start(t, srv.Server, pingtest.WithTLS(nil, clientCreds))
+
+	// 只有客户端证书，没有 token
+	res, err := healthpb.NewHealthClient(ts.Conn).Check(context.Background(), &healthpb.HealthCheckRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Status != healthpb.HealthCheckResponse_SERVING {
+		t.Errorf("status = %s", res.Status)
+	}
+	// 其他服务仍然需要 token
+	if _, err := ts.Client.Ping(context.Background(), &pb.PingRequest{Value: "ping"}); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("ping err = %v, want %s", err, codes.Unauthenticated)
+	}
 }
@@ -2,66 +2,134 @@ package main
 
 import (
 	"context"
-	"errors"
+	"crypto/x509"
 	"log"
 	"net"
 
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/metadata"
 
-	pb "github.com/jergoo/go-grpc-tutorial/protos/ping" // 引入编译生成的包
+	"github.com/jergoo/go-grpc-tutorial/certwatch"
+	"github.com/jergoo/go-grpc-tutorial/jwtauth"
+	"github.com/jergoo/go-grpc-tutorial/mtls"
+	"github.com/jergoo/go-grpc-tutorial/pingpong"
+	"github.com/jergoo/go-grpc-tutorial/ratelimit"
 )
 
-// PingPongServer 实现 pb.PingPongServer 接口
-type PingPongServer struct {
-	pb.UnimplementedPingPongServer // 兼容性需要，避免未实现server接口全部方法
+// 生成示例使用的证书，证书有效期为 10 年
+//go:generate go run ../cmd/certgen ca -dir keys
+//go:generate go run ../cmd/certgen server -dir keys -cn grpc.server -hosts grpc.server,localhost,127.0.0.1,::1 -validity 87600h
+//go:generate go run ../cmd/certgen client -dir keys -cn tutorial-client -uris spiffe://go-grpc-tutorial/client -validity 87600h
+
+// token 的签发方和接收方，客户端签发 token 时使用相同的值
+const (
+	issuer   = "go-grpc-tutorial"
+	audience = "protos.PingPong"
+)
+
+// healthMethods 健康检查服务的方法，只验证客户端证书，负载均衡器等不携带 token 也可以调用
+const healthMethods = "/grpc.health.v1.Health/*"
+
+// newVerifier 使用 keys/jwks.json 中的公钥验证 token，健康检查不验证 token
+func newVerifier() (*jwtauth.Verifier, error) {
+	keys, err := jwtauth.LoadJWKS("keys/jwks.json")
+	if err != nil {
+		return nil, err
+	}
+	return jwtauth.NewVerifier(keys,
+		jwtauth.WithIssuer(issuer),
+		jwtauth.WithAudience(audience),
+		jwtauth.WithSkipMethods(healthMethods),
+	), nil
+}
+
+// newPolicy 客户端证书身份授权策略
+func newPolicy() *mtls.Policy {
+	return mtls.NewPolicy().
+		Allow("spiffe://go-grpc-tutorial/client", "/protos.PingPong/*", "/grpc.reflection.*/*").
+		Allow(mtls.AnyIdentity, healthMethods)
 }
 
-// Ping 单次请求-响应模式
-func (s *PingPongServer) Ping(ctx context.Context, req *pb.PingRequest) (*pb.PongResponse, error) {
-	return &pb.PongResponse{Value: "pong"}, nil
+// newLimiter 按 token subject 限流，Ping 每秒 10 次，MultiPingPong 每秒打开 1 个流、接收 50 条消息
+func newLimiter() (*ratelimit.Limiter, error) {
+	return ratelimit.New(
+		ratelimit.WithKeyFunc(ratelimit.BySubject),
+		ratelimit.WithRule("/protos.PingPong/Ping", ratelimit.Rule{Calls: ratelimit.PerSecond(10, 20)}),
+		ratelimit.WithRule("/protos.PingPong/MultiPingPong", ratelimit.Rule{
+			Calls:    ratelimit.PerSecond(1, 5),
+			Messages: ratelimit.PerSecond(50, 100),
+		}),
+	)
 }
 
-// 服务端拦截器 - token 认证
-func authInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
-	md, ok := metadata.FromIncomingContext(ctx)
-	if !ok {
-		return nil, errors.New("authorization missing")
+// interceptors 先根据证书身份授权，再验证 Token，最后按 Token 的 subject 限流
+//
+// 流请求同样需要授权和 Token，包括反射服务，健康检查只需要证书。
+func interceptors(verifier *jwtauth.Verifier, limiter *ratelimit.Limiter) []grpc.ServerOption {
+	policy := newPolicy()
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(policy.UnaryServerInterceptor(), verifier.UnaryServerInterceptor(), limiter.UnaryServerInterceptor()),
+		grpc.ChainStreamInterceptor(policy.StreamServerInterceptor(), verifier.StreamServerInterceptor(), limiter.StreamServerInterceptor()),
 	}
+}
 
-	var token string
-	if auth, ok := md["authorization"]; ok {
-		token = auth[0]
+// responseHeader 读取认证通过的调用方身份，并通过响应metadata返回
+func responseHeader(ctx context.Context, method string) metadata.MD {
+	md := metadata.MD{}
+	if id, ok := mtls.FromContext(ctx); ok {
+		log.Printf("%s called by peer %s", method, id)
+		md.Set("peer", id.String())
 	}
-	if token != "1234567890" {
-		return nil, grpc.Errorf(codes.Unauthenticated, "token invalid")
+	if claims, ok := jwtauth.FromContext(ctx); ok {
+		log.Printf("%s called by %s", method, claims.Subject)
+		md.Set("subject", claims.Subject)
 	}
-
-	// 处理请求
-	return handler(ctx, req)
+	return md
 }
 
 // 启动server
 func main() {
-	// 加载证书文件
-	creds, err := credentials.NewServerTLSFromFile("keys/server.crt", "keys/server.key")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// 加载证书文件，使用 CA 证书验证客户端证书，文件变化后自动重新加载
+	certs, err := certwatch.New("keys/server.crt", "keys/server.key",
+		certwatch.WithClientCA("keys/ca.crt"),
+		certwatch.WithReloadHandler(func(leaf *x509.Certificate) {
+			log.Printf("load crt %s, expires at %s", leaf.SerialNumber, leaf.NotAfter)
+		}),
+	)
 	if err != nil {
 		log.Fatalf("load crt fail:%v", err)
 	}
-	opts := []grpc.ServerOption{
-		grpc.Creds(creds),                      // TLS
-		grpc.UnaryInterceptor(authInterceptor), // Token
+	go certs.Run(ctx)
+	// 加载 token 验证密钥
+	verifier, err := newVerifier()
+	if err != nil {
+		log.Fatalf("load jwks fail:%v", err)
 	}
+	limiter, err := newLimiter()
+	if err != nil {
+		log.Fatal(err)
+	}
+	opts := append([]grpc.ServerOption{
+		grpc.Creds(credentials.NewTLS(certs.TLSConfig())), // mTLS
+	}, interceptors(verifier, limiter)...)
 
-	srv := grpc.NewServer(opts...)
-	// 注册 PingPongServer
-	pb.RegisterPingPongServer(srv, &PingPongServer{})
+	srv := pingpong.NewServer(
+		pingpong.WithGRPCOptions(opts...),
+		pingpong.WithPingPongOptions(pingpong.WithHeaderFunc(responseHeader)),
+	)
 	lis, err := net.Listen("tcp", ":1234")
 	if err != nil {
 		log.Fatal(err)
 	}
 	log.Println("listen on 1234")
-	srv.Serve(lis)
+	// 收到 SIGINT/SIGTERM 后优雅退出
+	report, err := srv.Run(ctx, lis)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("server stopped: %s", report)
 }
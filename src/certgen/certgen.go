@@ -0,0 +1,321 @@
+// Package certgen 生成示例使用的 CA、服务端和客户端证书
+//
+// 只依赖标准库，生成的证书和私钥为 PEM 格式，私钥使用 PKCS#8 编码，
+// 可以直接通过 tls.LoadX509KeyPair 或 mtls.ServerTLSConfig/ClientTLSConfig 加载。
+package certgen
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"net/url"
+	"os"
+	"time"
+)
+
+// KeyType 私钥类型
+type KeyType string
+
+const (
+	// ECDSA P-256 私钥
+	ECDSA KeyType = "ecdsa"
+	// RSA 私钥，长度由 WithRSABits 指定
+	RSA KeyType = "rsa"
+)
+
+const (
+	// DefaultRSABits 默认 RSA 私钥长度
+	DefaultRSABits = 2048
+	// DefaultCAValidity 默认 CA 证书有效期
+	DefaultCAValidity = 10 * 365 * 24 * time.Hour
+	// DefaultValidity 默认服务端和客户端证书有效期
+	DefaultValidity = 365 * 24 * time.Hour
+)
+
+// options 证书生成配置
+type options struct {
+	keyType  KeyType
+	rsaBits  int
+	validity time.Duration
+	hosts    []string
+	uris     []string
+	now      func() time.Time
+}
+
+// Option 证书生成配置项
+type Option func(*options)
+
+// WithKeyType 指定私钥类型，默认 ECDSA
+func WithKeyType(t KeyType) Option {
+	return func(o *options) {
+		o.keyType = t
+	}
+}
+
+// WithRSABits 指定 RSA 私钥长度，默认 2048
+func WithRSABits(bits int) Option {
+	return func(o *options) {
+		o.rsaBits = bits
+	}
+}
+
+// WithValidity 指定证书有效期
+func WithValidity(d time.Duration) Option {
+	return func(o *options) {
+		o.validity = d
+	}
+}
+
+// WithHosts 指定证书 SAN 中的域名或 IP，如 grpc.server、localhost、127.0.0.1
+func WithHosts(hosts ...string) Option {
+	return func(o *options) {
+		o.hosts = append(o.hosts, hosts...)
+	}
+}
+
+// WithURIs 指定证书 SAN 中的 URI，如 SPIFFE ID spiffe://example.org/client
+func WithURIs(uris ...string) Option {
+	return func(o *options) {
+		o.uris = append(o.uris, uris...)
+	}
+}
+
+// WithTimeFunc 指定证书生效时间的时钟，用于测试
+func WithTimeFunc(now func() time.Time) Option {
+	return func(o *options) {
+		o.now = now
+	}
+}
+
+func newOptions(validity time.Duration, opts []Option) *options {
+	o := &options{
+		keyType:  ECDSA,
+		rsaBits:  DefaultRSABits,
+		validity: validity,
+		now:      time.Now,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// Certificate 证书及其私钥
+type Certificate struct {
+	Cert *x509.Certificate
+	Key  crypto.Signer
+}
+
+// NewCA 生成自签名的 CA 证书
+func NewCA(commonName string, opts ...Option) (*Certificate, error) {
+	o := newOptions(DefaultCAValidity, opts)
+	tmpl, err := newTemplate(commonName, o)
+	if err != nil {
+		return nil, err
+	}
+	tmpl.IsCA = true
+	tmpl.BasicConstraintsValid = true
+	tmpl.MaxPathLenZero = true
+	tmpl.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature
+	return create(tmpl, nil, o)
+}
+
+// IssueServer 使用 CA 签发服务端证书，证书 SAN 由 WithHosts 指定，未指定时使用 commonName
+func (ca *Certificate) IssueServer(commonName string, opts ...Option) (*Certificate, error) {
+	o := newOptions(DefaultValidity, opts)
+	if len(o.hosts) == 0 {
+		o.hosts = []string{commonName}
+	}
+	return ca.issue(commonName, x509.ExtKeyUsageServerAuth, o)
+}
+
+// IssueClient 使用 CA 签发 mTLS 客户端证书
+func (ca *Certificate) IssueClient(commonName string, opts ...Option) (*Certificate, error) {
+	return ca.issue(commonName, x509.ExtKeyUsageClientAuth, newOptions(DefaultValidity, opts))
+}
+
+func (ca *Certificate) issue(commonName string, usage x509.ExtKeyUsage, o *options) (*Certificate, error) {
+	if !ca.Cert.IsCA {
+		return nil, fmt.Errorf("%s is not a CA certificate", ca.Cert.Subject.CommonName)
+	}
+	tmpl, err := newTemplate(commonName, o)
+	if err != nil {
+		return nil, err
+	}
+	tmpl.KeyUsage = x509.KeyUsageDigitalSignature
+	if o.keyType == RSA {
+		tmpl.KeyUsage |= x509.KeyUsageKeyEncipherment
+	}
+	tmpl.ExtKeyUsage = []x509.ExtKeyUsage{usage}
+	if tmpl.NotAfter.After(ca.Cert.NotAfter) {
+		tmpl.NotAfter = ca.Cert.NotAfter
+	}
+	return create(tmpl, ca, o)
+}
+
+// newTemplate 根据配置生成证书模板
+func newTemplate(commonName string, o *options) (*x509.Certificate, error) {
+	if commonName == "" {
+		return nil, errors.New("common name required")
+	}
+	if o.validity <= 0 {
+		return nil, fmt.Errorf("invalid validity %s", o.validity)
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+	now := o.now()
+	tmpl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: commonName},
+		// 允许少量时钟偏差
+		NotBefore: now.Add(-5 * time.Minute),
+		NotAfter:  now.Add(o.validity),
+	}
+	for _, h := range o.hosts {
+		if ip := net.ParseIP(h); ip != nil {
+			tmpl.IPAddresses = append(tmpl.IPAddresses, ip)
+		} else {
+			tmpl.DNSNames = append(tmpl.DNSNames, h)
+		}
+	}
+	for _, s := range o.uris {
+		u, err := url.Parse(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid uri %q: %w", s, err)
+		}
+		tmpl.URIs = append(tmpl.URIs, u)
+	}
+	return tmpl, nil
+}
+
+// create 生成私钥并签发证书，parent 为 nil 时自签名
+func create(tmpl *x509.Certificate, parent *Certificate, o *options) (*Certificate, error) {
+	key, err := generateKey(o)
+	if err != nil {
+		return nil, err
+	}
+	issuer, signer := tmpl, key
+	if parent != nil {
+		issuer, signer = parent.Cert, parent.Key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, issuer, key.Public(), signer)
+	if err != nil {
+		return nil, err
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+	return &Certificate{Cert: cert, Key: key}, nil
+}
+
+func generateKey(o *options) (crypto.Signer, error) {
+	switch o.keyType {
+	case ECDSA:
+		return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case RSA:
+		if o.rsaBits < 2048 {
+			return nil, fmt.Errorf("rsa key size %d too small", o.rsaBits)
+		}
+		return rsa.GenerateKey(rand.Reader, o.rsaBits)
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", o.keyType)
+	}
+}
+
+// CertPEM 返回 PEM 格式的证书
+func (c *Certificate) CertPEM() []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.Cert.Raw})
+}
+
+// KeyPEM 返回 PEM 格式的 PKCS#8 私钥
+func (c *Certificate) KeyPEM() ([]byte, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(c.Key)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+// WriteFiles 写入证书和私钥文件，私钥文件权限为 0600
+func (c *Certificate) WriteFiles(certFile, keyFile string) error {
+	keyPEM, err := c.KeyPEM()
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(certFile, c.CertPEM(), 0o644); err != nil {
+		return err
+	}
+	return os.WriteFile(keyFile, keyPEM, 0o600)
+}
+
+// Load 读取 PEM 格式的证书和私钥，用于复用已有的 CA 签发证书
+func Load(certFile, keyFile string) (*Certificate, error) {
+	certPEM, err := os.ReadFile(certFile)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(certPEM)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, fmt.Errorf("no certificate found in %s", certFile)
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	keyPEM, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, err
+	}
+	block, _ = pem.Decode(keyPEM)
+	if block == nil {
+		return nil, fmt.Errorf("no private key found in %s", keyFile)
+	}
+	key, err := parseKey(block)
+	if err != nil {
+		return nil, err
+	}
+	if !publicKeyEqual(cert.PublicKey, key.Public()) {
+		return nil, fmt.Errorf("private key in %s does not match %s", keyFile, certFile)
+	}
+	return &Certificate{Cert: cert, Key: key}, nil
+}
+
+// parseKey 解析 PKCS#8、PKCS#1 或 SEC 1 格式的私钥
+func parseKey(block *pem.Block) (crypto.Signer, error) {
+	var key interface{}
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key %T", key)
+	}
+	return signer, nil
+}
+
+func publicKeyEqual(a, b crypto.PublicKey) bool {
+	k, ok := a.(interface{ Equal(crypto.PublicKey) bool })
+	return ok && k.Equal(b)
+}
@@ -0,0 +1,145 @@
+package certgen
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestIssue(t *testing.T) {
+	tests := []struct {
+		name    string
+		keyType KeyType
+		wantErr bool
+	}{
+		{name: "ecdsa", keyType: ECDSA},
+		{name: "rsa", keyType: RSA},
+		{name: "unsupported", keyType: "ed25519", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ca, err := NewCA("test CA", WithKeyType(tt.keyType))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %t", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			server, err := ca.IssueServer("grpc.server", WithKeyType(tt.keyType), WithHosts("grpc.server", "localhost", "127.0.0.1", "::1"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			client, err := ca.IssueClient("client", WithKeyType(tt.keyType), WithURIs("spiffe://example.org/client"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			roots := x509.NewCertPool()
+			roots.AddCert(ca.Cert)
+			for _, host := range []string{"grpc.server", "localhost", "127.0.0.1", "::1"} {
+				_, err := server.Cert.Verify(x509.VerifyOptions{DNSName: host, Roots: roots})
+				if err != nil {
+					t.Errorf("verify server %s: %v", host, err)
+				}
+			}
+			if _, err := client.Cert.Verify(x509.VerifyOptions{Roots: roots, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}}); err != nil {
+				t.Errorf("verify client: %v", err)
+			}
+			if _, err := server.Cert.Verify(x509.VerifyOptions{Roots: roots, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}}); err == nil {
+				t.Error("server certificate accepted for client auth")
+			}
+			if len(client.Cert.URIs) != 1 || client.Cert.URIs[0].String() != "spiffe://example.org/client" {
+				t.Errorf("client uris = %v", client.Cert.URIs)
+			}
+		})
+	}
+}
+
+func TestValidity(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := WithTimeFunc(func() time.Time { return now })
+	ca, err := NewCA("test CA", clock, WithValidity(48*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		validity time.Duration
+		notAfter time.Time
+		wantErr  bool
+	}{
+		{name: "within ca", validity: time.Hour, notAfter: now.Add(time.Hour)},
+		{name: "capped by ca", validity: 72 * time.Hour, notAfter: ca.Cert.NotAfter},
+		{name: "invalid", validity: -time.Hour, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cert, err := ca.IssueServer("grpc.server", clock, WithValidity(tt.validity))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %t", err, tt.wantErr)
+			}
+			if err == nil && !cert.Cert.NotAfter.Equal(tt.notAfter) {
+				t.Errorf("not after = %s, want %s", cert.Cert.NotAfter, tt.notAfter)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	ca, err := NewCA("test CA")
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := NewCA("other CA", WithKeyType(RSA))
+	if err != nil {
+		t.Fatal(err)
+	}
+	caCert, caKey := filepath.Join(dir, "ca.crt"), filepath.Join(dir, "ca.key")
+	otherCert, otherKey := filepath.Join(dir, "other.crt"), filepath.Join(dir, "other.key")
+	if err := ca.WriteFiles(caCert, caKey); err != nil {
+		t.Fatal(err)
+	}
+	if err := other.WriteFiles(otherCert, otherKey); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		cert    string
+		key     string
+		wantErr bool
+	}{
+		{name: "ecdsa", cert: caCert, key: caKey},
+		{name: "rsa", cert: otherCert, key: otherKey},
+		{name: "key mismatch", cert: caCert, key: otherKey, wantErr: true},
+		{name: "key as cert", cert: caKey, key: caKey, wantErr: true},
+		{name: "missing", cert: filepath.Join(dir, "missing.crt"), key: caKey, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loaded, err := Load(tt.cert, tt.key)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %t", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			// 加载的 CA 可以继续签发证书，并能被 tls 包直接使用
+			server, err := loaded.IssueServer("grpc.server")
+			if err != nil {
+				t.Fatal(err)
+			}
+			certFile, keyFile := filepath.Join(t.TempDir(), "server.crt"), filepath.Join(t.TempDir(), "server.key")
+			if err := server.WriteFiles(certFile, keyFile); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := tls.LoadX509KeyPair(certFile, keyFile); err != nil {
+				t.Errorf("load key pair: %v", err)
+			}
+		})
+	}
+}
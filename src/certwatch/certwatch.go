@@ -0,0 +1,225 @@
+// Package certwatch 热加载 TLS 证书
+//
+// Watcher 定期检查证书、私钥和客户端 CA 文件，内容变化后重新加载，
+// 新的握手通过 GetCertificate/GetConfigForClient 使用新证书，已建立的连接不受影响。
+// 替换的文件无效（如证书与私钥不匹配、证书已过期）时保留原证书继续使用。
+package certwatch
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultInterval 默认文件检查间隔
+const DefaultInterval = 10 * time.Second
+
+// options Watcher 配置
+type options struct {
+	caFile   string
+	interval time.Duration
+	onReload func(leaf *x509.Certificate)
+	onError  func(err error)
+	now      func() time.Time
+}
+
+// Option Watcher 配置项
+type Option func(*options)
+
+// WithClientCA 指定验证客户端证书的 CA 文件，开启双向 TLS 认证，CA 文件同样热加载
+func WithClientCA(caFile string) Option {
+	return func(o *options) {
+		o.caFile = caFile
+	}
+}
+
+// WithInterval 指定文件检查间隔，默认 10s
+func WithInterval(d time.Duration) Option {
+	return func(o *options) {
+		if d > 0 {
+			o.interval = d
+		}
+	}
+}
+
+// WithReloadHandler 指定证书重新加载成功后的回调
+func WithReloadHandler(f func(leaf *x509.Certificate)) Option {
+	return func(o *options) {
+		o.onReload = f
+	}
+}
+
+// WithErrorHandler 指定重新加载失败时的回调，默认输出日志
+func WithErrorHandler(f func(err error)) Option {
+	return func(o *options) {
+		o.onError = f
+	}
+}
+
+// WithTimeFunc 指定检查证书有效期使用的时钟，用于测试
+func WithTimeFunc(now func() time.Time) Option {
+	return func(o *options) {
+		o.now = now
+	}
+}
+
+// keyPair 一次加载的证书、私钥和客户端 CA
+type keyPair struct {
+	cert   *tls.Certificate
+	leaf   *x509.Certificate
+	pool   *x509.CertPool
+	config *tls.Config
+	// 文件内容，用于判断文件是否变化
+	certPEM, keyPEM, caPEM []byte
+}
+
+// Watcher 证书热加载
+type Watcher struct {
+	certFile, keyFile string
+	opts              *options
+
+	// mu 保证同一时间只有一次加载
+	mu      sync.Mutex
+	current atomic.Pointer[keyPair]
+}
+
+// New 加载证书并创建 Watcher，首次加载失败时返回错误
+func New(certFile, keyFile string, opts ...Option) (*Watcher, error) {
+	o := &options{
+		interval: DefaultInterval,
+		onError: func(err error) {
+			log.Printf("certwatch: %v", err)
+		},
+		now: time.Now,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	w := &Watcher{certFile: certFile, keyFile: keyFile, opts: o}
+	if _, err := w.Reload(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Run 按间隔检查文件变化，直到 ctx 结束
+func (w *Watcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.opts.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := w.Reload(); err != nil && w.opts.onError != nil {
+				w.opts.onError(err)
+			}
+		}
+	}
+}
+
+// Reload 立即检查文件，内容变化时重新加载，返回是否替换了证书
+//
+// 新文件无效时返回错误并继续使用原证书。
+func (w *Watcher) Reload() (bool, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	certPEM, err := os.ReadFile(w.certFile)
+	if err != nil {
+		return false, err
+	}
+	keyPEM, err := os.ReadFile(w.keyFile)
+	if err != nil {
+		return false, err
+	}
+	var caPEM []byte
+	if w.opts.caFile != "" {
+		if caPEM, err = os.ReadFile(w.opts.caFile); err != nil {
+			return false, err
+		}
+	}
+	if old := w.current.Load(); old != nil &&
+		bytes.Equal(old.certPEM, certPEM) && bytes.Equal(old.keyPEM, keyPEM) && bytes.Equal(old.caPEM, caPEM) {
+		return false, nil
+	}
+
+	kp, err := w.parse(certPEM, keyPEM, caPEM)
+	if err != nil {
+		return false, err
+	}
+	w.current.Store(kp)
+	if w.opts.onReload != nil {
+		w.opts.onReload(kp.leaf)
+	}
+	return true, nil
+}
+
+// parse 解析并校验证书文件内容
+func (w *Watcher) parse(certPEM, keyPEM, caPEM []byte) (*keyPair, error) {
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("load %s: %w", w.certFile, err)
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", w.certFile, err)
+	}
+	cert.Leaf = leaf
+	if now := w.opts.now(); now.After(leaf.NotAfter) || now.Before(leaf.NotBefore) {
+		return nil, fmt.Errorf("%s is not valid at %s (valid from %s to %s)",
+			w.certFile, now.Format(time.RFC3339), leaf.NotBefore.Format(time.RFC3339), leaf.NotAfter.Format(time.RFC3339))
+	}
+
+	kp := &keyPair{cert: &cert, leaf: leaf, certPEM: certPEM, keyPEM: keyPEM, caPEM: caPEM}
+	kp.config = &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+		// GetConfigForClient 返回的配置不会继承 credentials.NewTLS 设置的 ALPN
+		NextProtos: []string{"h2"},
+	}
+	if caPEM != nil {
+		kp.pool = x509.NewCertPool()
+		if !kp.pool.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("no certificate found in %s", w.opts.caFile)
+		}
+		kp.config.ClientAuth = tls.RequireAndVerifyClientCert
+		kp.config.ClientCAs = kp.pool
+	}
+	return kp, nil
+}
+
+// GetCertificate 返回当前证书，用于 tls.Config.GetCertificate
+func (w *Watcher) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return w.current.Load().cert, nil
+}
+
+// GetConfigForClient 返回使用当前证书和客户端 CA 的配置，用于 tls.Config.GetConfigForClient
+func (w *Watcher) GetConfigForClient(*tls.ClientHelloInfo) (*tls.Config, error) {
+	return w.current.Load().config, nil
+}
+
+// TLSConfig 服务端 TLS 配置，每次握手使用最新加载的证书和客户端 CA
+func (w *Watcher) TLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		GetConfigForClient: w.GetConfigForClient,
+	}
+}
+
+// Leaf 当前使用的证书
+func (w *Watcher) Leaf() *x509.Certificate {
+	return w.current.Load().leaf
+}
+
+// NotAfter 当前证书的过期时间，可用于证书过期告警
+func (w *Watcher) NotAfter() time.Time {
+	return w.Leaf().NotAfter
+}
@@ -0,0 +1,232 @@
+package certwatch
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+
+	"github.com/jergoo/go-grpc-tutorial/certgen"
+	"github.com/jergoo/go-grpc-tutorial/pingpong"
+	"github.com/jergoo/go-grpc-tutorial/pingtest"
+	pb "github.com/jergoo/go-grpc-tutorial/protos/ping" // 引入编译生成的包
+)
+
+// testFiles 测试使用的证书文件
+type testFiles struct {
+	certFile, keyFile, caFile     string
+	ca                            *certgen.Certificate
+	clientCertFile, clientKeyFile string
+}
+
+func newTestFiles(t *testing.T) *testFiles {
+	t.Helper()
+	dir := t.TempDir()
+	f := &testFiles{
+		certFile:       filepath.Join(dir, "server.crt"),
+		keyFile:        filepath.Join(dir, "server.key"),
+		caFile:         filepath.Join(dir, "ca.crt"),
+		clientCertFile: filepath.Join(dir, "client.crt"),
+		clientKeyFile:  filepath.Join(dir, "client.key"),
+	}
+	f.rotateCA(t)
+	f.writeServer(t, f.issueServer(t))
+	return f
+}
+
+// rotateCA 生成新的 CA 并签发客户端证书
+func (f *testFiles) rotateCA(t *testing.T) {
+	t.Helper()
+	ca, err := certgen.NewCA("test CA")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := ca.IssueClient("client")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.WriteFiles(f.clientCertFile, f.clientKeyFile); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(f.caFile, ca.CertPEM(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	f.ca = ca
+}
+
+func (f *testFiles) issueServer(t *testing.T, opts ...certgen.Option) *certgen.Certificate {
+	t.Helper()
+	cert, err := f.ca.IssueServer("grpc.server", opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+func (f *testFiles) writeServer(t *testing.T, cert *certgen.Certificate) {
+	t.Helper()
+	if err := cert.WriteFiles(f.certFile, f.keyFile); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// clientCreds 客户端证书，使用 roots 验证服务端
+func (f *testFiles) clientCreds(t *testing.T, roots ...*certgen.Certificate) credentials.TransportCredentials {
+	t.Helper()
+	cert, err := tls.LoadX509KeyPair(f.clientCertFile, f.clientKeyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool := x509.NewCertPool()
+	for _, r := range roots {
+		pool.AddCert(r.Cert)
+	}
+	return credentials.NewTLS(&tls.Config{Certificates: []tls.Certificate{cert}, RootCAs: pool, ServerName: "grpc.server"})
+}
+
+// serverSerial 调用 Ping 并返回服务端证书序列号
+func serverSerial(t *testing.T, client pb.PingPongClient) string {
+	t.Helper()
+	var p peer.Peer
+	if _, err := client.Ping(context.Background(), &pb.PingRequest{Value: "ping"}, grpc.Peer(&p)); err != nil {
+		t.Fatal(err)
+	}
+	return p.AuthInfo.(credentials.TLSInfo).State.PeerCertificates[0].SerialNumber.String()
+}
+
+func TestReload(t *testing.T) {
+	f := newTestFiles(t)
+	w, err := New(f.certFile, f.keyFile, WithClientCA(f.caFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldCA := f.ca
+	srv := pingtest.NewServer(t, pingpong.NewPingPongServer(),
+		pingtest.WithTLS(credentials.NewTLS(w.TLSConfig()), f.clientCreds(t, oldCA)))
+	if got, want := serverSerial(t, srv.Client), w.Leaf().SerialNumber.String(); got != want {
+		t.Fatalf("serial = %s, want %s", got, want)
+	}
+	oldSerial := w.Leaf().SerialNumber.String()
+
+	// 未变化时不重新加载
+	if reloaded, err := w.Reload(); err != nil || reloaded {
+		t.Fatalf("reload unchanged = %t, %v", reloaded, err)
+	}
+
+	// 替换服务端证书，新连接使用新证书，已有连接不受影响
+	next := f.issueServer(t)
+	f.writeServer(t, next)
+	if reloaded, err := w.Reload(); err != nil || !reloaded {
+		t.Fatalf("reload = %t, %v", reloaded, err)
+	}
+	conn := srv.Dial(t, grpc.WithTransportCredentials(f.clientCreds(t, oldCA)))
+	if got, want := serverSerial(t, pb.NewPingPongClient(conn)), next.Cert.SerialNumber.String(); got != want {
+		t.Errorf("new connection serial = %s, want %s", got, want)
+	}
+	if got := serverSerial(t, srv.Client); got != oldSerial {
+		t.Errorf("existing connection serial = %s, want %s", got, oldSerial)
+	}
+	if !w.NotAfter().Equal(next.Cert.NotAfter) {
+		t.Errorf("not after = %s, want %s", w.NotAfter(), next.Cert.NotAfter)
+	}
+
+	// 替换客户端 CA，新 CA 签发的客户端证书可以连接
+	f.rotateCA(t)
+	f.writeServer(t, f.issueServer(t))
+	if _, err := w.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	conn = srv.Dial(t, grpc.WithTransportCredentials(f.clientCreds(t, f.ca)))
+	if got, want := serverSerial(t, pb.NewPingPongClient(conn)), w.Leaf().SerialNumber.String(); got != want {
+		t.Errorf("rotated ca serial = %s, want %s", got, want)
+	}
+}
+
+func TestReloadInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		write func(t *testing.T, f *testFiles)
+	}{
+		{name: "key mismatch", write: func(t *testing.T, f *testFiles) {
+			next := f.issueServer(t)
+			other := f.issueServer(t)
+			keyPEM, _ := other.KeyPEM()
+			os.WriteFile(f.certFile, next.CertPEM(), 0o644)
+			os.WriteFile(f.keyFile, keyPEM, 0o600)
+		}},
+		{name: "expired", write: func(t *testing.T, f *testFiles) {
+			f.writeServer(t, f.issueServer(t, certgen.WithTimeFunc(func() time.Time { return time.Now().Add(-48 * time.Hour) }), certgen.WithValidity(time.Hour)))
+		}},
+		{name: "truncated", write: func(t *testing.T, f *testFiles) {
+			os.WriteFile(f.certFile, []byte("-----BEGIN CERTIFICATE-----\n"), 0o644)
+		}},
+		{name: "missing", write: func(t *testing.T, f *testFiles) {
+			os.Remove(f.keyFile)
+		}},
+		{name: "invalid ca", write: func(t *testing.T, f *testFiles) {
+			os.WriteFile(f.caFile, []byte("not a certificate"), 0o644)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newTestFiles(t)
+			w, err := New(f.certFile, f.keyFile, WithClientCA(f.caFile))
+			if err != nil {
+				t.Fatal(err)
+			}
+			leaf := w.Leaf()
+			tt.write(t, f)
+			if _, err := w.Reload(); err == nil {
+				t.Fatal("invalid replacement accepted")
+			}
+			if w.Leaf() != leaf {
+				t.Error("certificate replaced")
+			}
+			cert, _ := w.GetCertificate(nil)
+			if cert.Leaf != leaf {
+				t.Error("GetCertificate returned replaced certificate")
+			}
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	f := newTestFiles(t)
+	reloaded := make(chan *x509.Certificate, 1)
+	w, err := New(f.certFile, f.keyFile,
+		WithInterval(10*time.Millisecond),
+		WithReloadHandler(func(leaf *x509.Certificate) {
+			select {
+			case reloaded <- leaf:
+			default:
+			}
+		}),
+		// 证书和私钥不是同时写入，检查时可能读到不匹配的文件
+		WithErrorHandler(func(err error) {}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-reloaded // 首次加载
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go w.Run(ctx)
+
+	next := f.issueServer(t)
+	f.writeServer(t, next)
+	select {
+	case leaf := <-reloaded:
+		if leaf.SerialNumber.Cmp(next.Cert.SerialNumber) != 0 {
+			t.Errorf("serial = %s, want %s", leaf.SerialNumber, next.Cert.SerialNumber)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("certificate not reloaded")
+	}
+}
@@ -0,0 +1,108 @@
+// pingbench PingPong 服务的吞吐测试
+//
+// 用法：
+//
+//	pingbench [-target localhost:1234] [-mode download|upload] [-c 4] [-s 8] [-d 10s]
+//	          [-n 100] [-size 1024] [-interval 0] [-o text|json]
+//
+// -c 指定连接数，-s 指定每个连接上的并发流数，-n、-size、-interval 指定每次调用的消息数、
+// 每条消息的负载字节数和发送间隔。download 调用 MultiPong 测试服务端到客户端方向，
+// upload 调用 MultiPing 测试客户端到服务端方向，服务端需要允许接收 -n 条消息。
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/jergoo/go-grpc-tutorial/pingbench"
+)
+
+func main() {
+	log.SetFlags(0)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if err := run(ctx, os.Args[1:], os.Stdout); err != nil {
+		stop()
+		log.Fatal(err)
+	}
+}
+
+func run(ctx context.Context, args []string, w io.Writer) error {
+	fs := flag.NewFlagSet("pingbench", flag.ContinueOnError)
+	target := fs.String("target", "localhost:1234", "server address")
+	mode := fs.String("mode", pingbench.ModeDownload, "download (MultiPong) or upload (MultiPing)")
+	conns := fs.Int("c", pingbench.DefaultConns, "number of connections")
+	streams := fs.Int("s", pingbench.DefaultStreams, "concurrent streams per connection")
+	duration := fs.Duration("d", pingbench.DefaultDuration, "test duration")
+	count := fs.Int("n", pingbench.DefaultCount, "messages per call")
+	size := fs.Int("size", pingbench.DefaultPayloadSize, "payload bytes per message")
+	interval := fs.Duration("interval", 0, "interval between messages")
+	output := fs.String("o", "text", "output format: text or json")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *output != "text" && *output != "json" {
+		return fmt.Errorf("unknown output format %q", *output)
+	}
+
+	r, err := pingbench.Run(ctx, *target,
+		pingbench.WithMode(*mode),
+		pingbench.WithConcurrency(*conns, *streams),
+		pingbench.WithDuration(*duration),
+		pingbench.WithMessages(*count, *size, *interval),
+	)
+	if r == nil {
+		return err
+	}
+	// 中断时输出已完成部分的结果
+	if *output == "json" {
+		return json.NewEncoder(w).Encode(newReport(r))
+	}
+	_, werr := fmt.Fprintln(w, r)
+	return werr
+}
+
+// report json 格式的测试报告，时间单位毫秒
+type report struct {
+	Mode           string  `json:"mode"`
+	Conns          int     `json:"conns"`
+	Streams        int     `json:"streams"`
+	ElapsedMs      float64 `json:"elapsed_ms"`
+	Calls          int     `json:"calls"`
+	Errors         int     `json:"errors"`
+	Messages       int64   `json:"messages"`
+	Bytes          int64   `json:"bytes"`
+	MessagesPerSec float64 `json:"msgs_per_sec"`
+	MBPerSec       float64 `json:"mb_per_sec"`
+	LatencyP50Ms   float64 `json:"latency_p50_ms"`
+	LatencyP90Ms   float64 `json:"latency_p90_ms"`
+	LatencyP99Ms   float64 `json:"latency_p99_ms"`
+	LatencyMaxMs   float64 `json:"latency_max_ms"`
+	LastError      string  `json:"last_error,omitempty"`
+}
+
+func ms(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+func newReport(r *pingbench.Result) report {
+	rep := report{
+		Mode: r.Mode, Conns: r.Conns, Streams: r.Streams, ElapsedMs: ms(r.Elapsed),
+		Calls: r.Calls, Errors: r.Errors, Messages: r.Messages, Bytes: r.Bytes,
+		MessagesPerSec: r.MessagesPerSec(), MBPerSec: r.MBPerSec(),
+		LatencyP50Ms: ms(r.Latency.P50), LatencyP90Ms: ms(r.Latency.P90),
+		LatencyP99Ms: ms(r.Latency.P99), LatencyMaxMs: ms(r.Latency.Max),
+	}
+	if r.LastErr != nil {
+		rep.LastError = r.LastErr.Error()
+	}
+	return rep
+}
@@ -0,0 +1,375 @@
+// pingctl 命令行调用 PingPong 服务的四种模式
+//
+// 用法：
+//
+//	pingctl ping       [flags]   // 单次请求，-n 指定调用次数
+//	pingctl multi-pong [flags]   // 服务端流
+//	pingctl multi-ping [flags]   // 客户端流，-n 指定发送消息数
+//	pingctl bidi       [flags]   // 双向流，-n 指定发送消息数
+//	pingctl latency    [flags]   // 按 -interval 发送 -n 次 Ping 并统计延迟，-n 0 持续发送直到中断
+//
+// 通用参数：
+//
+//	-target localhost:1234       服务地址
+//	-tls -ca ca.crt              开启 TLS 并使用 ca.crt 验证服务端证书，-cert/-key 指定客户端证书
+//	-server-name grpc.server     验证的服务端证书名称
+//	-token xxx                   以 authorization: Bearer xxx 发送 token
+//	-H key:value                 添加请求 metadata，可重复
+//	-n 5 -interval 500ms         消息数量和发送间隔
+//	-deadline 5s                 每次调用的超时时间
+//	-reply-count -reply-interval -payload-size -batch -echo -max -close-on-limit
+//	                             流的行为配置，即请求中的 StreamOptions，客户端流在第一条消息中发送
+//	-o text|json                 输出格式，json 每行一个对象
+//	-resume                      multi-pong 流中断后重新连接，从最后收到的响应继续
+//
+// 输出包含响应 header、响应消息、trailer 和最终状态，调用失败时退出码为 1。
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/jergoo/go-grpc-tutorial/mtls"
+	"github.com/jergoo/go-grpc-tutorial/pingclient"
+	pb "github.com/jergoo/go-grpc-tutorial/protos/ping" // 引入编译生成的包
+)
+
+func main() {
+	log.SetFlags(0)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if err := run(ctx, os.Args[1:], os.Stdout); err != nil {
+		stop()
+		log.Fatal(err)
+	}
+}
+
+// commands 子命令
+var commands = map[string]func(ctx context.Context, c *pingclient.Client, f *flags, p *printer) error{
+	"ping":       runPing,
+	"multi-pong": runMultiPong,
+	"multi-ping": runMultiPing,
+	"bidi":       runBidi,
+	"latency":    runLatency,
+}
+
+func run(ctx context.Context, args []string, w io.Writer) error {
+	if len(args) == 0 {
+		return usage()
+	}
+	cmd, ok := commands[args[0]]
+	if !ok {
+		return usage()
+	}
+
+	var f flags
+	fs := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	f.register(fs)
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	p, err := newPrinter(w, f.output)
+	if err != nil {
+		return err
+	}
+	opts, err := f.clientOptions()
+	if err != nil {
+		return err
+	}
+	// grpc.Header 在流调用结束后才写入结果，流调用通过拦截器在收到第一条消息时输出 header
+	opts = append(opts, pingclient.WithStreamInterceptors(p.streamInterceptor))
+	c, err := pingclient.New(f.target, opts...)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	ctx = metadata.NewOutgoingContext(ctx, f.metadata())
+	err = cmd(ctx, c, &f, p)
+	p.status(err)
+	return err
+}
+
+func usage() error {
+	return errors.New("usage: pingctl ping|multi-pong|multi-ping|bidi|latency [flags]")
+}
+
+// flags 命令行参数
+type flags struct {
+	target     string
+	useTLS     bool
+	caFile     string
+	certFile   string
+	keyFile    string
+	serverName string
+	token      string
+	headers    headers
+	value      string
+	count      int
+	stream     streamFlags
+	resume     bool
+	interval   time.Duration
+	deadline   time.Duration
+	output     string
+}
+
+func (f *flags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.target, "target", "localhost:1234", "server address")
+	fs.BoolVar(&f.useTLS, "tls", false, "use TLS, implied by -ca and -cert")
+	fs.StringVar(&f.caFile, "ca", "", "CA certificate to verify the server, system roots if empty")
+	fs.StringVar(&f.certFile, "cert", "", "client certificate for mTLS")
+	fs.StringVar(&f.keyFile, "key", "", "client private key for mTLS")
+	fs.StringVar(&f.serverName, "server-name", "", "server name to verify, defaults to the target host")
+	fs.StringVar(&f.token, "token", "", "bearer token sent as authorization metadata")
+	fs.Var(&f.headers, "H", "request metadata as key:value, repeatable")
+	fs.StringVar(&f.value, "value", "ping", "request value")
+	fs.IntVar(&f.count, "n", 5, "number of calls for ping, messages for multi-ping and bidi")
+	fs.DurationVar(&f.interval, "interval", 0, "interval between calls or messages")
+	fs.DurationVar(&f.deadline, "deadline", 10*time.Second, "deadline of each call, 0 for none")
+	fs.StringVar(&f.output, "o", outputText, "output format: text or json")
+	fs.BoolVar(&f.resume, "resume", false, "multi-pong: reconnect and resume from the last reply when the stream breaks")
+	f.stream.register(fs)
+}
+
+// streamFlags StreamOptions 参数，未指定时使用服务端默认值
+type streamFlags struct {
+	replyCount    uint
+	replyInterval time.Duration
+	payloadSize   uint
+	batchSize     uint
+	echo          bool
+	maxMessages   uint
+	closeOnLimit  bool
+}
+
+func (s *streamFlags) register(fs *flag.FlagSet) {
+	fs.UintVar(&s.replyCount, "reply-count", 0, "multi-pong: number of replies")
+	fs.DurationVar(&s.replyInterval, "reply-interval", 0, "multi-pong: interval between replies")
+	fs.UintVar(&s.payloadSize, "payload-size", 0, "multi-pong: payload bytes per reply")
+	fs.UintVar(&s.batchSize, "batch", 0, "bidi: reply once every n messages")
+	fs.BoolVar(&s.echo, "echo", false, "reply with the request values instead of pong")
+	fs.UintVar(&s.maxMessages, "max", 0, "multi-ping, bidi: max messages the server accepts")
+	fs.BoolVar(&s.closeOnLimit, "close-on-limit", false, "multi-ping, bidi: end the stream normally instead of failing when -max is exceeded")
+}
+
+// options 生成请求中的 StreamOptions，全部未指定时返回 nil
+func (s *streamFlags) options() *pb.StreamOptions {
+	o := &pb.StreamOptions{
+		ReplyCount:  uint32(s.replyCount),
+		PayloadSize: uint32(s.payloadSize),
+		BatchSize:   uint32(s.batchSize),
+		MaxMessages: uint32(s.maxMessages),
+	}
+	if s.replyInterval > 0 {
+		o.ReplyInterval = durationpb.New(s.replyInterval)
+	}
+	if s.echo {
+		o.ReplyMode = pb.StreamOptions_REPLY_MODE_ECHO
+	}
+	if s.closeOnLimit {
+		o.Termination = pb.StreamOptions_TERMINATION_CLOSE
+	}
+	if proto.Equal(o, &pb.StreamOptions{}) {
+		return nil
+	}
+	return o
+}
+
+// clientOptions 根据参数生成客户端配置
+func (f *flags) clientOptions() ([]pingclient.Option, error) {
+	opts := []pingclient.Option{pingclient.WithCallTimeout(f.deadline)}
+	if f.useTLS || f.caFile != "" || f.certFile != "" {
+		tlsConfig, err := f.tlsConfig()
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, pingclient.WithTransportCredentials(credentials.NewTLS(tlsConfig)))
+	}
+	return opts, nil
+}
+
+func (f *flags) tlsConfig() (*tls.Config, error) {
+	config := &tls.Config{ServerName: f.serverName, MinVersion: tls.VersionTLS12}
+	if f.caFile != "" {
+		pool, err := mtls.LoadCertPool(f.caFile)
+		if err != nil {
+			return nil, err
+		}
+		config.RootCAs = pool
+	}
+	if f.certFile != "" || f.keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(f.certFile, f.keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return config, nil
+}
+
+// metadata 请求 metadata，包含 -H 和 -token
+func (f *flags) metadata() metadata.MD {
+	md := metadata.MD{}
+	for _, h := range f.headers {
+		md.Append(h[0], h[1])
+	}
+	if f.token != "" {
+		md.Set("authorization", "Bearer "+f.token)
+	}
+	return md
+}
+
+// headers 可重复的 -H key:value 参数
+type headers [][2]string
+
+func (h *headers) String() string {
+	s := make([]string, len(*h))
+	for i, kv := range *h {
+		s[i] = kv[0] + ":" + kv[1]
+	}
+	return strings.Join(s, ",")
+}
+
+func (h *headers) Set(v string) error {
+	key, value, ok := strings.Cut(v, ":")
+	key = strings.TrimSpace(key)
+	if !ok || key == "" {
+		return fmt.Errorf("invalid header %q, want key:value", v)
+	}
+	*h = append(*h, [2]string{strings.ToLower(key), strings.TrimSpace(value)})
+	return nil
+}
+
+// runPing 调用 -n 次 Ping
+func runPing(ctx context.Context, c *pingclient.Client, f *flags, p *printer) error {
+	for i := 0; i < f.count; i++ {
+		if i > 0 && !sleep(ctx, f.interval) {
+			return ctx.Err()
+		}
+		var header, trailer metadata.MD
+		res, err := c.Ping(ctx, &pb.PingRequest{Value: f.value, Seq: uint64(i + 1)}, grpc.Header(&header), grpc.Trailer(&trailer))
+		p.header(header)
+		if err != nil {
+			p.trailer(trailer)
+			return err
+		}
+		p.response(res)
+		p.trailer(trailer)
+	}
+	return nil
+}
+
+// runMultiPong 调用 MultiPong 并输出全部响应，-resume 时流中断后从最后收到的响应继续
+func runMultiPong(ctx context.Context, c *pingclient.Client, f *flags, p *printer) error {
+	var header, trailer metadata.MD
+	req := &pb.PingRequest{Value: f.value, Options: f.stream.options()}
+	fn := func(res *pb.PongResponse) error {
+		p.response(res)
+		return nil
+	}
+	var err error
+	if f.resume {
+		err = c.ResumableMultiPong(ctx, req, fn,
+			pingclient.WithOnRetry(p.retry),
+			pingclient.WithCallOptions(grpc.Header(&header), grpc.Trailer(&trailer)),
+		)
+	} else {
+		err = c.MultiPongFunc(ctx, req, fn, grpc.Header(&header), grpc.Trailer(&trailer))
+	}
+	p.header(header)
+	p.trailer(trailer)
+	return err
+}
+
+// runMultiPing 发送 -n 个消息后输出服务端响应
+func runMultiPing(ctx context.Context, c *pingclient.Client, f *flags, p *printer) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var header, trailer metadata.MD
+	res, err := c.MultiPingFrom(ctx, pings(ctx, f, p), grpc.Header(&header), grpc.Trailer(&trailer))
+	p.header(header)
+	if err == nil {
+		p.response(res)
+	}
+	p.trailer(trailer)
+	return err
+}
+
+// runBidi 发送 -n 个消息，同时输出收到的响应
+func runBidi(ctx context.Context, c *pingclient.Client, f *flags, p *printer) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var header, trailer metadata.MD
+	err := c.MultiPingPongFunc(ctx, pings(ctx, f, p), func(res *pb.PongResponse) error {
+		p.response(res)
+		return nil
+	}, grpc.Header(&header), grpc.Trailer(&trailer))
+	p.header(header)
+	p.trailer(trailer)
+	return err
+}
+
+// runLatency 统计 Ping 的延迟，中断时输出已完成部分的统计
+func runLatency(ctx context.Context, c *pingclient.Client, f *flags, p *printer) error {
+	stats, err := c.Latency(ctx, f.count, f.interval, p.reply)
+	p.stats(stats)
+	if errors.Is(err, context.Canceled) {
+		return nil
+	}
+	return err
+}
+
+// pings 按 -interval 间隔输出 -n 个请求消息，发送完成或 ctx 取消后关闭
+func pings(ctx context.Context, f *flags, p *printer) <-chan *pb.PingRequest {
+	ch := make(chan *pb.PingRequest)
+	go func() {
+		defer close(ch)
+		for i := 0; i < f.count; i++ {
+			if i > 0 && !sleep(ctx, f.interval) {
+				return
+			}
+			req := &pb.PingRequest{Value: f.value, Seq: uint64(i + 1)}
+			if i == 0 {
+				req.Options = f.stream.options()
+			}
+			select {
+			case ch <- req:
+				p.request(req)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch
+}
+
+// sleep 等待 d，ctx 取消时返回 false
+func sleep(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
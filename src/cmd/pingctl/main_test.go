@@ -0,0 +1,214 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc/metadata"
+
+	"github.com/jergoo/go-grpc-tutorial/pingpong"
+)
+
+// startServer 在本地端口启动 PingPong 服务，header 回显请求中的 x-echo，trailer 固定为 t: v
+func startServer(t *testing.T) string {
+	t.Helper()
+	srv := pingpong.NewServer(pingpong.WithPingPongOptions(
+		pingpong.WithPongCount(3),
+		pingpong.WithReplay(pingpong.DefaultReplaySize, pingpong.DefaultReplayTTL),
+		pingpong.WithHeaderFunc(func(ctx context.Context, method string) metadata.MD {
+			md, _ := metadata.FromIncomingContext(ctx)
+			return metadata.Pairs("x-echo", strings.Join(md.Get("x-echo"), ","), "auth", strings.Join(md.Get("authorization"), ","))
+		}),
+		pingpong.WithTrailerFunc(func(ctx context.Context, method string) metadata.MD {
+			return metadata.Pairs("t", "v")
+		}),
+	))
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+	return lis.Addr().String()
+}
+
+// parseEvents 解析 json 输出
+func parseEvents(t *testing.T, out []byte) []event {
+	t.Helper()
+	var events []event
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	for sc.Scan() {
+		var e event
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			t.Fatalf("invalid line %q: %v", sc.Text(), err)
+		}
+		events = append(events, e)
+	}
+	return events
+}
+
+func TestRun(t *testing.T) {
+	target := startServer(t)
+	tests := []struct {
+		name    string
+		args    []string
+		types   string // 输出事件类型，request 由另一个 goroutine 输出，不参与比较
+		code    string
+		wantErr bool
+	}{
+		{
+			name:  "ping",
+			args:  []string{"ping", "-n", "2"},
+			types: "header response trailer header response trailer status",
+			code:  "OK",
+		},
+		{
+			name:  "multi pong",
+			args:  []string{"multi-pong"},
+			types: "header response response response trailer status",
+			code:  "OK",
+		},
+		{
+			name:  "multi pong resume",
+			args:  []string{"multi-pong", "-resume", "-reply-count", "2"},
+			types: "header response response trailer status",
+			code:  "OK",
+		},
+		{
+			name:  "multi ping",
+			args:  []string{"multi-ping", "-n", "3", "-interval", "10ms"},
+			types: "header response trailer status",
+			code:  "OK",
+		},
+		{
+			name:    "multi ping exceeded",
+			args:    []string{"multi-ping", "-n", "10"},
+			types:   "header trailer status",
+			code:    "ResourceExhausted",
+			wantErr: true,
+		},
+		{
+			name:  "bidi",
+			args:  []string{"bidi", "-n", "4"},
+			types: "header response response trailer status",
+			code:  "OK",
+		},
+		{
+			name:  "multi pong options",
+			args:  []string{"multi-pong", "-reply-count", "2", "-reply-interval", "1ms", "-echo"},
+			types: "header response response trailer status",
+			code:  "OK",
+		},
+		{
+			name:  "multi ping max",
+			args:  []string{"multi-ping", "-n", "10", "-max", "10"},
+			types: "header response trailer status",
+			code:  "OK",
+		},
+		{
+			name:  "bidi batch",
+			args:  []string{"bidi", "-n", "3", "-batch", "1"},
+			types: "header response response response trailer status",
+			code:  "OK",
+		},
+		{
+			name:    "invalid options",
+			args:    []string{"multi-pong", "-payload-size", "2000000"},
+			types:   "trailer status",
+			code:    "InvalidArgument",
+			wantErr: true,
+		},
+		{
+			name:  "latency",
+			args:  []string{"latency", "-n", "3", "-interval", "1ms"},
+			types: "reply reply reply stats status",
+			code:  "OK",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var out bytes.Buffer
+			args := append(tt.args, "-target", target, "-o", "json", "-H", "X-Echo: hi", "-token", "abc")
+			err := run(context.Background(), args, &out)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v", err)
+			}
+
+			var types []string
+			events := parseEvents(t, out.Bytes())
+			for _, e := range events {
+				if e.Type == "request" {
+					continue
+				}
+				types = append(types, e.Type)
+				switch e.Type {
+				case "header":
+					if got := e.Metadata.Get("x-echo"); len(got) != 1 || got[0] != "hi" {
+						t.Errorf("x-echo = %v", got)
+					}
+					if got := e.Metadata.Get("auth"); len(got) != 1 || got[0] != "Bearer abc" {
+						t.Errorf("auth = %v", got)
+					}
+				case "trailer":
+					if got := e.Metadata.Get("t"); !tt.wantErr && (len(got) != 1 || got[0] != "v") {
+						t.Errorf("trailer = %v", e.Metadata)
+					}
+				case "stats":
+					data := e.Data.(map[string]interface{})
+					if data["sent"] != 3.0 || data["received"] != 3.0 || data["loss"] != 0.0 {
+						t.Errorf("stats = %v", data)
+					}
+				case "status":
+					if e.Code != tt.code {
+						t.Errorf("code = %s, want %s", e.Code, tt.code)
+					}
+				}
+			}
+			if got := strings.Join(types, " "); got != tt.types {
+				t.Errorf("events = %q, want %q", got, tt.types)
+			}
+		})
+	}
+}
+
+func TestText(t *testing.T) {
+	target := startServer(t)
+	var out bytes.Buffer
+	if err := run(context.Background(), []string{"multi-ping", "-n", "2", "-target", target}, &out); err != nil {
+		t.Fatal(err)
+	}
+	// 客户端流在发送结束后才响应，输出顺序固定
+	want := "request: {\"value\":\"ping\",\"seq\":\"1\"}\nrequest: {\"value\":\"ping\",\"seq\":\"2\"}\n" +
+		"header: auth: \nheader: content-type: application/grpc\nheader: x-echo: \n" +
+		"response: {\"value\":\"got 2 ping\",\"received\":\"2\"}\ntrailer: t: v\nstatus: OK\n"
+	if got := out.String(); got != want {
+		t.Errorf("output:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestFlags(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{name: "no command"},
+		{name: "unknown command", args: []string{"pong"}},
+		{name: "invalid header", args: []string{"ping", "-H", "novalue"}},
+		{name: "invalid output", args: []string{"ping", "-o", "yaml"}},
+		{name: "missing ca", args: []string{"ping", "-ca", "missing.crt"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := run(context.Background(), tt.args, &bytes.Buffer{}); err == nil {
+				t.Error("want error")
+			}
+		})
+	}
+}
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/jergoo/go-grpc-tutorial/pingclient"
+)
+
+// 输出格式
+const (
+	outputText = "text"
+	outputJSON = "json"
+)
+
+// event json 格式的一行输出
+type event struct {
+	Type     string          `json:"type"`
+	Metadata metadata.MD     `json:"metadata,omitempty"`
+	Message  json.RawMessage `json:"message,omitempty"`
+	Data     interface{}     `json:"data,omitempty"`
+	Code     string          `json:"code,omitempty"`
+	Error    string          `json:"error,omitempty"`
+}
+
+// printer 输出调用过程，并发安全
+//
+// 每次调用的 header 只输出一次，输出 trailer 后开始下一次调用。
+type printer struct {
+	mu         sync.Mutex
+	w          io.Writer
+	json       bool
+	headerDone bool
+}
+
+func newPrinter(w io.Writer, format string) (*printer, error) {
+	switch format {
+	case outputText:
+		return &printer{w: w}, nil
+	case outputJSON:
+		return &printer{w: w, json: true}, nil
+	default:
+		return nil, fmt.Errorf("unknown output format %q", format)
+	}
+}
+
+func (p *printer) header(md metadata.MD) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.headerDone {
+		return
+	}
+	p.headerDone = true
+	p.metadata("header", md)
+}
+
+func (p *printer) trailer(md metadata.MD) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.metadata("trailer", md)
+	p.headerDone = false
+}
+
+func (p *printer) request(m proto.Message) {
+	p.message("request", m)
+}
+
+func (p *printer) response(m proto.Message) {
+	p.message("response", m)
+}
+
+// replyJSON json 格式的延迟探测结果，时间单位毫秒
+type replyJSON struct {
+	Seq    uint64  `json:"seq"`
+	RTT    float64 `json:"rtt_ms,omitempty"`
+	Offset float64 `json:"offset_ms,omitempty"`
+	Error  string  `json:"error,omitempty"`
+}
+
+// statsJSON json 格式的延迟统计，时间单位毫秒
+type statsJSON struct {
+	Sent     int     `json:"sent"`
+	Received int     `json:"received"`
+	Loss     float64 `json:"loss"`
+	Min      float64 `json:"min_ms"`
+	Avg      float64 `json:"avg_ms"`
+	Max      float64 `json:"max_ms"`
+	StdDev   float64 `json:"stddev_ms"`
+	Jitter   float64 `json:"jitter_ms"`
+	Offset   float64 `json:"offset_ms"`
+}
+
+func ms(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// reply 输出一次延迟探测结果
+func (p *printer) reply(r pingclient.Reply) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.json {
+		data := replyJSON{Seq: r.Seq}
+		if r.Err != nil {
+			data.Error = r.Err.Error()
+		} else {
+			data.RTT, data.Offset = ms(r.RTT), ms(r.Offset)
+		}
+		p.writeJSON(event{Type: "reply", Data: data})
+		return
+	}
+	if r.Err != nil {
+		fmt.Fprintf(p.w, "seq=%d error: %s\n", r.Seq, status.Convert(r.Err).Message())
+		return
+	}
+	fmt.Fprintf(p.w, "seq=%d rtt=%s offset=%s\n", r.Seq, r.RTT, r.Offset)
+}
+
+// stats 输出延迟统计
+func (p *printer) stats(s *pingclient.Stats) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.json {
+		p.writeJSON(event{Type: "stats", Data: statsJSON{
+			Sent: s.Sent, Received: s.Received, Loss: s.Loss(),
+			Min: ms(s.Min), Avg: ms(s.Avg), Max: ms(s.Max), StdDev: ms(s.StdDev), Jitter: ms(s.Jitter), Offset: ms(s.Offset),
+		}})
+		return
+	}
+	fmt.Fprintf(p.w, "--- latency statistics ---\n%s\n", s)
+}
+
+// retryJSON json 格式的重试信息
+type retryJSON struct {
+	Attempt int     `json:"attempt"`
+	Delay   float64 `json:"delay_ms"`
+}
+
+// retry 输出断点续传的重试，重试的调用重新输出 header
+func (p *printer) retry(attempt int, delay time.Duration, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.headerDone = false
+	s := status.Convert(err)
+	if p.json {
+		p.writeJSON(event{Type: "retry", Data: retryJSON{Attempt: attempt, Delay: ms(delay)}, Code: s.Code().String(), Error: s.Message()})
+		return
+	}
+	fmt.Fprintf(p.w, "retry %d in %s: %s: %s\n", attempt, delay.Round(time.Millisecond), s.Code(), s.Message())
+}
+
+// status 输出调用最终状态
+func (p *printer) status(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	s := status.Convert(err)
+	if p.json {
+		p.writeJSON(event{Type: "status", Code: s.Code().String(), Error: s.Message()})
+		return
+	}
+	if err == nil {
+		fmt.Fprintf(p.w, "status: %s\n", s.Code())
+		return
+	}
+	fmt.Fprintf(p.w, "status: %s: %s\n", s.Code(), s.Message())
+}
+
+// metadata 输出 header 或 trailer，为空时不输出
+func (p *printer) metadata(typ string, md metadata.MD) {
+	if len(md) == 0 {
+		return
+	}
+	// 二进制值以 base64 输出
+	md = md.Copy()
+	for k, vs := range md {
+		if strings.HasSuffix(k, "-bin") {
+			for i, v := range vs {
+				vs[i] = base64.StdEncoding.EncodeToString([]byte(v))
+			}
+		}
+	}
+	if p.json {
+		p.writeJSON(event{Type: typ, Metadata: md})
+		return
+	}
+	keys := make([]string, 0, len(md))
+	for k := range md {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(p.w, "%s: %s: %s\n", typ, k, strings.Join(md[k], ", "))
+	}
+}
+
+func (p *printer) message(typ string, m proto.Message) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	data, err := protojson.Marshal(m)
+	if err != nil {
+		data = []byte(fmt.Sprintf("%q", err.Error()))
+	}
+	if p.json {
+		p.writeJSON(event{Type: typ, Message: data})
+		return
+	}
+	// protojson 的输出格式不固定，压缩后输出
+	var buf bytes.Buffer
+	json.Compact(&buf, data)
+	fmt.Fprintf(p.w, "%s: %s\n", typ, buf.Bytes())
+}
+
+func (p *printer) writeJSON(e event) {
+	data, _ := json.Marshal(e)
+	fmt.Fprintf(p.w, "%s\n", data)
+}
+
+// streamInterceptor 收到第一条流消息时输出 header
+func (p *printer) streamInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	cs, err := streamer(ctx, desc, cc, method, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &headerClientStream{ClientStream: cs, p: p}, nil
+}
+
+// headerClientStream 包装 grpc.ClientStream，接收消息前输出 header
+type headerClientStream struct {
+	grpc.ClientStream
+	p *printer
+}
+
+func (s *headerClientStream) RecvMsg(m interface{}) error {
+	err := s.ClientStream.RecvMsg(m)
+	if err == nil {
+		if md, herr := s.ClientStream.Header(); herr == nil {
+			s.p.header(md)
+		}
+	}
+	return err
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"io"
 	"log"
 	"time"
@@ -9,14 +10,15 @@ import (
 	"google.golang.org/grpc"
 
 	pb "github.com/jergoo/go-grpc-tutorial/protos/ping" // 引入编译生成的包
+	"github.com/jergoo/go-grpc-tutorial/rpcerr"
 )
 
 // Ping 单次请求-响应模式
-func Ping() {
-	// 以option方式添加拦截器
-	conn, err := grpc.Dial("localhost:1234", grpc.WithInsecure(), grpc.WithUnaryInterceptor(clientUnaryInterceptor))
+func Ping(target string, opts ...grpc.DialOption) error {
+	// 以option方式添加拦截器链，全部请求使用相同的拦截器
+	conn, err := grpc.Dial(target, dialOptions(opts...)...)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 	defer conn.Close()
 
@@ -24,16 +26,17 @@ func Ping() {
 	client := pb.NewPingPongClient(conn)
 	res, err := client.Ping(context.Background(), &pb.PingRequest{Value: "ping"})
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 	log.Println(res.Value)
+	return nil
 }
 
 // MultiPong 服务端流模式
-func MultiPong() {
-	conn, err := grpc.Dial("localhost:1234", grpc.WithInsecure())
+func MultiPong(target string, opts ...grpc.DialOption) error {
+	conn, err := grpc.Dial(target, dialOptions(opts...)...)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 	defer conn.Close()
 
@@ -41,7 +44,7 @@ func MultiPong() {
 	client := pb.NewPingPongClient(conn)
 	stream, err := client.MultiPong(context.Background(), &pb.PingRequest{Value: "ping"})
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 
 	// 循环接收数据流
@@ -51,17 +54,18 @@ func MultiPong() {
 			if err == io.EOF {
 				break
 			}
-			log.Fatal(err)
+			return err
 		}
 		log.Println(msg.Value)
 	}
+	return nil
 }
 
 // MultiPing 客户端流模式
-func MultiPing() {
-	conn, err := grpc.Dial("localhost:1234", grpc.WithInsecure())
+func MultiPing(target string, opts ...grpc.DialOption) error {
+	conn, err := grpc.Dial(target, dialOptions(opts...)...)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 	defer conn.Close()
 
@@ -69,36 +73,42 @@ func MultiPing() {
 	client := pb.NewPingPongClient(conn)
 	stream, err := client.MultiPing(context.Background())
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 
-	// 发送数据
+	// 发送数据，服务端默认最多接收 5 条消息
 	for i := 0; i < 6; i++ {
 		data := &pb.PingRequest{Value: "ping"}
 		err = stream.Send(data)
+		// 服务端提前结束时返回 io.EOF，错误通过 CloseAndRecv 获取
+		if err == io.EOF {
+			break
+		}
 		if err != nil {
-			log.Fatal(err)
+			return err
 		}
 	}
 
 	// 发送结束并获取服务端响应
 	res, err := stream.CloseAndRecv()
+	if e, ok := rpcerr.FromError(err); ok && errors.Is(e, rpcerr.ErrPingLimitExceeded) {
+		// 超出服务端限制，输出错误详情
+		log.Printf("ping limit exceeded, max %s: %s", e.Metadata["max"], e.LocalizedMessage)
+		return nil
+	}
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 
 	log.Println(res.Value)
+	return nil
 }
 
 // MultiPingPong 双向流模式
-func MultiPingPong() {
-	opts := []grpc.DialOption{
-		grpc.WithInsecure(),
-		grpc.WithStreamInterceptor(clientStreamInterceptor),
-	}
-	conn, err := grpc.Dial("localhost:1234", opts...)
+func MultiPingPong(target string, opts ...grpc.DialOption) error {
+	conn, err := grpc.Dial(target, dialOptions(opts...)...)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 	defer conn.Close()
 
@@ -106,23 +116,23 @@ func MultiPingPong() {
 	client := pb.NewPingPongClient(conn)
 	stream, err := client.MultiPingPong(context.Background())
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 
-	// 在另一个goroutine中接收数据
-	c := make(chan struct{})
-	go func(stream pb.PingPong_MultiPingPongClient, c chan struct{}) {
+	// 在另一个goroutine中接收数据，接收结束后返回错误信息
+	c := make(chan error, 1)
+	go func(stream pb.PingPong_MultiPingPongClient, c chan error) {
 		for {
 			msg, err := stream.Recv()
 			if err != nil {
 				if err == io.EOF {
-					break
+					err = nil
 				}
-				log.Fatal(err)
+				c <- err
+				return
 			}
 			log.Printf("recv:%s\n", msg.Value)
 		}
-		c <- struct{}{}
 	}(stream, c)
 
 	// 发送数据
@@ -130,7 +140,7 @@ func MultiPingPong() {
 		data := &pb.PingRequest{Value: "ping"}
 		err = stream.Send(data)
 		if err != nil {
-			log.Fatal(err)
+			return err
 		}
 		log.Printf("send:%s\n", data.Value)
 		time.Sleep(500 * time.Millisecond)
@@ -139,7 +149,7 @@ func MultiPingPong() {
 	// 结束发送
 	stream.CloseSend()
 	// 等待接收完成
-	<-c
+	return <-c
 }
 
 // type UnaryClientInterceptor func(ctx context.Context, method string, req, reply interface{}, cc *ClientConn, invoker UnaryInvoker, opts ...CallOption) error
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/jergoo/go-grpc-tutorial/logging"
+	"github.com/jergoo/go-grpc-tutorial/metrics"
+	"github.com/jergoo/go-grpc-tutorial/middleware"
+	"github.com/jergoo/go-grpc-tutorial/ratelimit"
+	"github.com/jergoo/go-grpc-tutorial/recovery"
+	"github.com/jergoo/go-grpc-tutorial/tracing"
+	"github.com/jergoo/go-grpc-tutorial/validator"
+)
+
+// 示例服务端和客户端的指标，导出方式见 monitor 示例
+var (
+	serverMetrics = metrics.NewServerMetrics()
+	clientMetrics = metrics.NewClientMetrics()
+)
+
+// registry 示例使用的拦截器，同一个名称下包含服务端和客户端拦截器
+var registry = newRegistry()
+
+// newRegistry 注册各个包提供的拦截器和本章编写的拦截器
+func newRegistry() *middleware.Registry {
+	// 按调用方 IP 限流，Ping 每秒 10 次
+	limiter, err := ratelimit.New(ratelimit.WithRule("/protos.PingPong/Ping", ratelimit.Rule{Calls: ratelimit.PerSecond(10, 20)}))
+	if err != nil {
+		log.Fatal(err)
+	}
+	return middleware.NewRegistry().
+		MustRegister("recovery", recovery.Middleware()).
+		MustRegister("tracing", tracing.Middleware()).
+		MustRegister("metrics", metrics.Middleware(serverMetrics, clientMetrics)).
+		MustRegister("logging", logging.Middleware()).
+		MustRegister("ratelimit", limiter.Middleware()).
+		MustRegister("validator", validator.Middleware()).
+		// 本章编写的拦截器
+		MustRegister("example", middleware.Middleware{
+			UnaryServer:  serverUnaryInterceptor,
+			StreamServer: serverStreamInterceptor,
+			UnaryClient:  clientUnaryInterceptor,
+			StreamClient: clientStreamInterceptor,
+		}).
+		MustRegister("timing", middleware.Middleware{
+			UnaryServer: timingUnaryInterceptor,
+			UnaryClient: timingClientInterceptor,
+		})
+}
+
+// newChain 拦截器链，服务端和客户端使用相同的顺序和方法范围
+//
+// recovery 在最外层，之后依次创建 span、记录指标、输出日志，健康检查不输出日志，
+// 限流和参数校验只对 PingPong 服务生效。
+func newChain() *middleware.Chain {
+	chain, err := registry.Build(
+		middleware.Use("recovery"),
+		middleware.Use("tracing"),
+		middleware.Use("metrics"),
+		middleware.Use("logging").Except("/grpc.health.v1.Health/*"),
+		middleware.Use("ratelimit", "/protos.PingPong/*"),
+		middleware.Use("validator", "/protos.PingPong/*"),
+		middleware.Use("timing", "/protos.PingPong/*"),
+		middleware.Use("example").Except("/grpc.health.v1.Health/*"),
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return chain
+}
+
+// serverOptions 服务端拦截器配置
+func serverOptions() []grpc.ServerOption {
+	return newChain().ServerOptions()
+}
+
+// dialOptions 客户端连接配置，opts 追加在拦截器之后
+func dialOptions(opts ...grpc.DialOption) []grpc.DialOption {
+	return append(append([]grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}, newChain().DialOptions()...), opts...)
+}
+
+// 服务端拦截器 - 记录请求耗时
+func timingUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	start := time.Now()
+	resp, err := handler(ctx, req)
+	log.Printf("[Server Timing] %s took %s", info.FullMethod, time.Since(start))
+	return resp, err
+}
+
+// 客户端拦截器 - 记录请求耗时
+func timingClientInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	start := time.Now()
+	err := invoker(ctx, method, req, reply, cc, opts...)
+	log.Printf("[Client Timing] %s took %s", method, time.Since(start))
+	return err
+}
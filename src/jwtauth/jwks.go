@@ -0,0 +1,166 @@
+package jwtauth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+)
+
+// Key JWKS 中的单个密钥
+type Key struct {
+	ID        string      // kid
+	Algorithm string      // alg，如 HS256、RS256、ES256
+	Key       interface{} // []byte、*rsa.PublicKey 或 *ecdsa.PublicKey
+}
+
+// KeySet 验证 token 签名使用的密钥集合
+type KeySet struct {
+	keys map[string]*Key
+}
+
+// jwk JWKS 文件中的密钥格式，见 RFC 7517
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	// oct
+	K string `json:"k"`
+	// RSA
+	N string `json:"n"`
+	E string `json:"e"`
+	// EC
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// LoadJWKS 读取本地 JWKS 文件
+func LoadJWKS(path string) (*KeySet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseJWKS(data)
+}
+
+// ParseJWKS 解析 JWKS 内容，每个密钥需指定 kid 和 alg
+func ParseJWKS(data []byte) (*KeySet, error) {
+	var jwks struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &jwks); err != nil {
+		return nil, fmt.Errorf("parse jwks: %w", err)
+	}
+
+	ks := &KeySet{keys: make(map[string]*Key)}
+	for _, k := range jwks.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := parseJWK(k)
+		if err != nil {
+			return nil, fmt.Errorf("parse jwk %q: %w", k.Kid, err)
+		}
+		if _, ok := ks.keys[key.ID]; ok {
+			return nil, fmt.Errorf("duplicate kid %q", key.ID)
+		}
+		ks.keys[key.ID] = key
+	}
+	if len(ks.keys) == 0 {
+		return nil, errors.New("jwks has no signing keys")
+	}
+	return ks, nil
+}
+
+// Lookup 根据 kid 查找密钥
+func (ks *KeySet) Lookup(kid string) (*Key, bool) {
+	key, ok := ks.keys[kid]
+	return key, ok
+}
+
+// Algorithms 返回密钥集合支持的签名算法
+func (ks *KeySet) Algorithms() []string {
+	seen := make(map[string]bool)
+	var algs []string
+	for _, key := range ks.keys {
+		if !seen[key.Algorithm] {
+			seen[key.Algorithm] = true
+			algs = append(algs, key.Algorithm)
+		}
+	}
+	return algs
+}
+
+func parseJWK(k jwk) (*Key, error) {
+	if k.Kid == "" {
+		return nil, errors.New("kid missing")
+	}
+	key := &Key{ID: k.Kid, Algorithm: k.Alg}
+
+	switch k.Kty {
+	case "oct":
+		if k.Alg != "HS256" {
+			return nil, fmt.Errorf("alg %q not supported for oct key", k.Alg)
+		}
+		secret, err := decodeSegment(k.K)
+		if err != nil {
+			return nil, err
+		}
+		key.Key = secret
+	case "RSA":
+		if k.Alg != "RS256" {
+			return nil, fmt.Errorf("alg %q not supported for RSA key", k.Alg)
+		}
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		key.Key = &rsa.PublicKey{N: n, E: int(e.Int64())}
+	case "EC":
+		if k.Alg != "ES256" || k.Crv != "P-256" {
+			return nil, fmt.Errorf("alg %q crv %q not supported for EC key", k.Alg, k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		pub := &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}
+		if !pub.Curve.IsOnCurve(x, y) {
+			return nil, errors.New("point not on curve")
+		}
+		key.Key = pub
+	default:
+		return nil, fmt.Errorf("kty %q not supported", k.Kty)
+	}
+	return key, nil
+}
+
+func decodeSegment(s string) ([]byte, error) {
+	if s == "" {
+		return nil, errors.New("empty key parameter")
+	}
+	return base64.RawURLEncoding.DecodeString(s)
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := decodeSegment(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
@@ -0,0 +1,224 @@
+// Package jwtauth 基于 JWT 的 bearer token 认证
+//
+// 服务端使用本地 JWKS 文件中的密钥验证 HS256/RS256/ES256 签名，并校验 exp、nbf、aud、iss，
+// 验证通过后将 Claims 保存在请求 context 中，处理方法通过 FromContext 获取调用方身份。
+package jwtauth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/jergoo/go-grpc-tutorial/rpcerr"
+)
+
+// Claims token 中的声明
+type Claims struct {
+	jwt.RegisteredClaims
+	Scope string `json:"scope,omitempty"`
+}
+
+// Option Verifier 配置项
+type Option func(*Verifier)
+
+// WithIssuer 要求 token 的 iss 与 issuer 一致
+func WithIssuer(issuer string) Option {
+	return func(v *Verifier) {
+		v.issuer = issuer
+	}
+}
+
+// WithAudience 要求 token 的 aud 包含 audience
+func WithAudience(audience string) Option {
+	return func(v *Verifier) {
+		v.audience = audience
+	}
+}
+
+// WithLeeway 校验 exp、nbf 时允许的时钟误差
+func WithLeeway(d time.Duration) Option {
+	return func(v *Verifier) {
+		v.leeway = d
+	}
+}
+
+// WithTimeFunc 设置校验时使用的当前时间，用于测试
+func WithTimeFunc(now func() time.Time) Option {
+	return func(v *Verifier) {
+		v.now = now
+	}
+}
+
+// WithSkipMethods 拦截器不验证 methods 指定的方法，支持 path.Match 通配，如 /grpc.health.v1.Health/*，
+// 用于健康检查等不携带 token 的调用
+func WithSkipMethods(methods ...string) Option {
+	return func(v *Verifier) {
+		v.skip = append(v.skip, methods...)
+	}
+}
+
+// Verifier 验证 token
+type Verifier struct {
+	keys     *KeySet
+	issuer   string
+	audience string
+	leeway   time.Duration
+	now      func() time.Time
+	skip     []string
+}
+
+// NewVerifier 创建 Verifier，token 必须包含 exp
+func NewVerifier(keys *KeySet, opts ...Option) *Verifier {
+	v := &Verifier{keys: keys, now: time.Now}
+	for _, opt := range opts {
+		opt(v)
+	}
+	return v
+}
+
+// Verify 验证 token 签名和声明，返回解析后的 Claims
+func (v *Verifier) Verify(token string) (*Claims, error) {
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods(v.keys.Algorithms()),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(v.leeway),
+		jwt.WithTimeFunc(v.now),
+	}
+	if v.issuer != "" {
+		opts = append(opts, jwt.WithIssuer(v.issuer))
+	}
+	if v.audience != "" {
+		opts = append(opts, jwt.WithAudience(v.audience))
+	}
+
+	claims := &Claims{}
+	_, err := jwt.ParseWithClaims(token, claims, v.keyFunc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// keyFunc 根据 kid 查找密钥，并确认 token 的 alg 与密钥一致
+func (v *Verifier) keyFunc(t *jwt.Token) (interface{}, error) {
+	kid, _ := t.Header["kid"].(string)
+	key, ok := v.keys.Lookup(kid)
+	if !ok {
+		return nil, fmt.Errorf("unknown kid %q", kid)
+	}
+	if t.Method.Alg() != key.Algorithm {
+		return nil, fmt.Errorf("alg %q does not match key %q", t.Method.Alg(), kid)
+	}
+	return key.Key, nil
+}
+
+// Authenticate 从请求 metadata 中读取 bearer token 并验证，返回包含 Claims 的 context
+//
+// 验证失败返回 codes.Unauthenticated 错误。
+func (v *Verifier) Authenticate(ctx context.Context) (context.Context, error) {
+	token, err := bearerToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+	claims, err := v.Verify(token)
+	if err != nil {
+		return nil, rpcerr.Newf(codes.Unauthenticated, "token invalid: %v", err).WithReason(rpcerr.ReasonTokenInvalid, nil).Err()
+	}
+	return NewContext(ctx, claims), nil
+}
+
+// bearerToken 读取 authorization metadata 中的 token
+func bearerToken(ctx context.Context) (string, error) {
+	values := metadata.ValueFromIncomingContext(ctx, "authorization")
+	if len(values) == 0 {
+		return "", rpcerr.New(codes.Unauthenticated, "authorization missing").WithReason(rpcerr.ReasonAuthorizationMissing, nil).Err()
+	}
+	scheme, token, ok := strings.Cut(values[0], " ")
+	if !ok || !strings.EqualFold(scheme, "bearer") || token == "" {
+		return "", rpcerr.New(codes.Unauthenticated, "authorization must be a bearer token").WithReason(rpcerr.ReasonTokenInvalid, nil).Err()
+	}
+	return token, nil
+}
+
+// UnaryServerInterceptor 服务端拦截器 - token 认证
+func (v *Verifier) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if v.skipped(info.FullMethod) {
+			return handler(ctx, req)
+		}
+		ctx, err := v.Authenticate(ctx)
+		if err != nil {
+			return nil, err
+		}
+		// 处理请求
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor 服务端流拦截器 - token 认证
+//
+// 与 UnaryServerInterceptor 使用相同的验证逻辑，处理方法通过 stream.Context() 获取 Claims。
+func (v *Verifier) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if v.skipped(info.FullMethod) {
+			return handler(srv, ss)
+		}
+		ctx, err := v.Authenticate(ss.Context())
+		if err != nil {
+			return err
+		}
+		// 处理请求，使用包含 Claims 的 context
+		return handler(srv, &authServerStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// skipped 判断 method 是否不需要验证
+func (v *Verifier) skipped(method string) bool {
+	for _, pattern := range v.skip {
+		if ok, _ := path.Match(pattern, method); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// authServerStream 包装 grpc.ServerStream，替换 Context
+type authServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authServerStream) Context() context.Context {
+	return s.ctx
+}
+
+type claimsKey struct{}
+
+// NewContext 返回保存了 claims 的 context
+func NewContext(ctx context.Context, claims *Claims) context.Context {
+	return context.WithValue(ctx, claimsKey{}, claims)
+}
+
+// FromContext 获取认证通过的 Claims
+func FromContext(ctx context.Context) (*Claims, bool) {
+	claims, ok := ctx.Value(claimsKey{}).(*Claims)
+	return claims, ok
+}
+
+// Sign 使用 key 签发 token，kid 写入 header 用于服务端查找密钥
+func Sign(method jwt.SigningMethod, key interface{}, kid string, claims jwt.Claims) (string, error) {
+	if method == nil || method == jwt.SigningMethodNone {
+		return "", errors.New("signing method required")
+	}
+	t := jwt.NewWithClaims(method, claims)
+	t.Header["kid"] = kid
+	return t.SignedString(key)
+}
@@ -0,0 +1,215 @@
+package jwtauth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// testKeys 测试使用的密钥，JWKS 只包含验证所需的部分
+type testKeys struct {
+	hmac []byte
+	rsa  *rsa.PrivateKey
+	ec   *ecdsa.PrivateKey
+	jwks []byte
+}
+
+func newTestKeys(t *testing.T) *testKeys {
+	t.Helper()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k := &testKeys{hmac: []byte("0123456789abcdef0123456789abcdef"), rsa: rsaKey, ec: ecKey}
+
+	enc := base64.RawURLEncoding.EncodeToString
+	jwks := map[string][]map[string]string{"keys": {
+		{"kty": "oct", "kid": "hs", "alg": "HS256", "k": enc(k.hmac)},
+		{"kty": "RSA", "kid": "rs", "alg": "RS256", "n": enc(rsaKey.N.Bytes()), "e": enc(big.NewInt(int64(rsaKey.E)).Bytes())},
+		{"kty": "EC", "kid": "es", "alg": "ES256", "crv": "P-256", "x": enc(ecKey.X.FillBytes(make([]byte, 32))), "y": enc(ecKey.Y.FillBytes(make([]byte, 32)))},
+	}}
+	k.jwks, err = json.Marshal(jwks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return k
+}
+
+func TestVerify(t *testing.T) {
+	keys := newTestKeys(t)
+	ks, err := ParseJWKS(keys.jwks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	v := NewVerifier(ks, WithIssuer("issuer"), WithAudience("aud"), WithTimeFunc(func() time.Time { return now }))
+
+	claims := func(mod func(*jwt.RegisteredClaims)) jwt.RegisteredClaims {
+		c := jwt.RegisteredClaims{
+			Subject:   "alice",
+			Issuer:    "issuer",
+			Audience:  jwt.ClaimStrings{"aud"},
+			NotBefore: jwt.NewNumericDate(now.Add(-time.Minute)),
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+		}
+		if mod != nil {
+			mod(&c)
+		}
+		return c
+	}
+
+	tests := []struct {
+		name    string
+		method  jwt.SigningMethod
+		key     interface{}
+		kid     string
+		claims  jwt.RegisteredClaims
+		wantErr bool
+	}{
+		{name: "HS256", method: jwt.SigningMethodHS256, key: keys.hmac, kid: "hs", claims: claims(nil)},
+		{name: "RS256", method: jwt.SigningMethodRS256, key: keys.rsa, kid: "rs", claims: claims(nil)},
+		{name: "ES256", method: jwt.SigningMethodES256, key: keys.ec, kid: "es", claims: claims(nil)},
+		{name: "expired", method: jwt.SigningMethodES256, key: keys.ec, kid: "es", wantErr: true,
+			claims: claims(func(c *jwt.RegisteredClaims) { c.ExpiresAt = jwt.NewNumericDate(now.Add(-time.Second)) })},
+		{name: "missing exp", method: jwt.SigningMethodES256, key: keys.ec, kid: "es", wantErr: true,
+			claims: claims(func(c *jwt.RegisteredClaims) { c.ExpiresAt = nil })},
+		{name: "not before", method: jwt.SigningMethodES256, key: keys.ec, kid: "es", wantErr: true,
+			claims: claims(func(c *jwt.RegisteredClaims) { c.NotBefore = jwt.NewNumericDate(now.Add(time.Minute)) })},
+		{name: "wrong audience", method: jwt.SigningMethodES256, key: keys.ec, kid: "es", wantErr: true,
+			claims: claims(func(c *jwt.RegisteredClaims) { c.Audience = jwt.ClaimStrings{"other"} })},
+		{name: "wrong issuer", method: jwt.SigningMethodES256, key: keys.ec, kid: "es", wantErr: true,
+			claims: claims(func(c *jwt.RegisteredClaims) { c.Issuer = "other" })},
+		{name: "unknown kid", method: jwt.SigningMethodES256, key: keys.ec, kid: "unknown", claims: claims(nil), wantErr: true},
+		{name: "alg mismatch", method: jwt.SigningMethodHS256, key: keys.hmac, kid: "rs", claims: claims(nil), wantErr: true},
+		{name: "wrong key", method: jwt.SigningMethodHS256, key: []byte("wrong"), kid: "hs", claims: claims(nil), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := Sign(tt.method, tt.key, tt.kid, tt.claims)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := v.Verify(token)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %t", err, tt.wantErr)
+			}
+			if err == nil && got.Subject != "alice" {
+				t.Errorf("subject = %q, want %q", got.Subject, "alice")
+			}
+		})
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	keys := newTestKeys(t)
+	ks, err := ParseJWKS(keys.jwks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := NewVerifier(ks)
+	token, err := Sign(jwt.SigningMethodHS256, keys.hmac, "hs", jwt.RegisteredClaims{
+		Subject:   "alice",
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		md   metadata.MD
+		code codes.Code
+	}{
+		{name: "bearer", md: metadata.Pairs("authorization", "Bearer "+token)},
+		{name: "no metadata", code: codes.Unauthenticated},
+		{name: "missing", md: metadata.Pairs("other", "x"), code: codes.Unauthenticated},
+		{name: "raw token", md: metadata.Pairs("authorization", token), code: codes.Unauthenticated},
+		{name: "invalid", md: metadata.Pairs("authorization", "Bearer 1234567890"), code: codes.Unauthenticated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.md != nil {
+				ctx = metadata.NewIncomingContext(ctx, tt.md)
+			}
+			ctx, err := v.Authenticate(ctx)
+			if status.Code(err) != tt.code {
+				t.Fatalf("code = %v, want %v", status.Code(err), tt.code)
+			}
+			if err != nil {
+				return
+			}
+			claims, ok := FromContext(ctx)
+			if !ok || claims.Subject != "alice" {
+				t.Errorf("claims = %v", claims)
+			}
+		})
+	}
+}
+
+func TestSkipMethods(t *testing.T) {
+	keys := newTestKeys(t)
+	ks, err := ParseJWKS(keys.jwks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := NewVerifier(ks, WithSkipMethods("/grpc.health.v1.Health/*"))
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) { return "ok", nil }
+
+	tests := []struct {
+		method string
+		code   codes.Code
+	}{
+		{method: "/grpc.health.v1.Health/Check"},
+		{method: "/grpc.health.v1.Health/Watch"},
+		{method: "/protos.PingPong/Ping", code: codes.Unauthenticated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method, func(t *testing.T) {
+			// 不携带 token
+			_, err := v.UnaryServerInterceptor()(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: tt.method}, handler)
+			if status.Code(err) != tt.code {
+				t.Errorf("code = %v, want %v", status.Code(err), tt.code)
+			}
+		})
+	}
+}
+
+func TestParseJWKS(t *testing.T) {
+	tests := []struct {
+		name    string
+		jwks    string
+		wantErr bool
+	}{
+		{name: "empty", jwks: `{"keys":[]}`, wantErr: true},
+		{name: "missing kid", jwks: `{"keys":[{"kty":"oct","alg":"HS256","k":"c2VjcmV0"}]}`, wantErr: true},
+		{name: "unsupported kty", jwks: `{"keys":[{"kty":"OKP","kid":"a","alg":"EdDSA"}]}`, wantErr: true},
+		{name: "alg mismatch", jwks: `{"keys":[{"kty":"oct","kid":"a","alg":"RS256","k":"c2VjcmV0"}]}`, wantErr: true},
+		{name: "encryption key skipped", jwks: `{"keys":[{"kty":"oct","kid":"a","alg":"HS256","k":"c2VjcmV0"},{"kty":"oct","kid":"b","use":"enc"}]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseJWKS([]byte(tt.jwks))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("err = %v, wantErr %t", err, tt.wantErr)
+			}
+		})
+	}
+}
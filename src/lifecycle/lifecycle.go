@@ -0,0 +1,157 @@
+// Package lifecycle 管理 grpc Server 的运行和优雅退出
+//
+// Serve 在收到 SIGINT/SIGTERM 或 context 取消时，先将健康状态置为 NOT_SERVING 并结束 health Watch 等长连接流，
+// 再调用 GracefulStop 等待进行中的请求完成，超过排空时间后调用 Stop 强制退出。
+package lifecycle
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+)
+
+// DefaultDrainTimeout 默认排空等待时间
+const DefaultDrainTimeout = 30 * time.Second
+
+// Server grpc Server 需要实现的方法，*grpc.Server 满足该接口
+type Server interface {
+	Serve(net.Listener) error
+	GracefulStop()
+	Stop()
+}
+
+// Option Serve 配置项
+type Option func(*options)
+
+type options struct {
+	drainTimeout time.Duration
+	signals      []os.Signal
+	health       *health.Server
+	tracker      *Tracker
+	streams      *StreamCloser
+}
+
+// WithDrainTimeout 设置排空等待时间，超时后强制退出，d <= 0 不等待
+func WithDrainTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.drainTimeout = d
+	}
+}
+
+// WithSignals 设置触发退出的信号，默认 SIGINT、SIGTERM
+func WithSignals(sig ...os.Signal) Option {
+	return func(o *options) {
+		o.signals = sig
+	}
+}
+
+// WithHealth 退出时将 health 中所有服务置为 NOT_SERVING
+func WithHealth(hs *health.Server) Option {
+	return func(o *options) {
+		o.health = hs
+	}
+}
+
+// WithTracker 使用 tracker 统计被强制中断的请求数，tracker 需注册到对应的 Server
+func WithTracker(t *Tracker) Option {
+	return func(o *options) {
+		o.tracker = t
+	}
+}
+
+// WithStreamCloser 退出时在排空前结束 closer 记录的长连接流，closer 需注册到对应的 Server
+func WithStreamCloser(c *StreamCloser) Option {
+	return func(o *options) {
+		o.streams = c
+	}
+}
+
+// Report 退出报告
+type Report struct {
+	Graceful       bool          // 是否在排空时间内正常退出
+	Duration       time.Duration // 退出耗时
+	AbortedUnary   int           // 被中断的单次请求数
+	AbortedStreams int           // 被中断的流数
+}
+
+// String 格式化退出报告
+func (r Report) String() string {
+	return fmt.Sprintf("graceful=%t duration=%s aborted_unary=%d aborted_streams=%d",
+		r.Graceful, r.Duration, r.AbortedUnary, r.AbortedStreams)
+}
+
+// Serve 运行 srv 直到收到退出信号或 ctx 取消，然后优雅退出
+//
+// srv.Serve 异常返回时直接返回对应错误；正常退出时返回退出报告。
+func Serve(ctx context.Context, srv Server, lis net.Listener, opts ...Option) (Report, error) {
+	o := &options{
+		drainTimeout: DefaultDrainTimeout,
+		signals:      []os.Signal{syscall.SIGINT, syscall.SIGTERM},
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, o.signals...)
+	defer stop()
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- srv.Serve(lis)
+	}()
+
+	select {
+	case err := <-errc:
+		return Report{}, err
+	case <-ctx.Done():
+	}
+
+	report := shutdown(srv, o)
+	// GracefulStop/Stop 之后 Serve 返回 nil，Serve 尚未开始时返回 ErrServerStopped
+	if err := <-errc; err != nil && err != grpc.ErrServerStopped {
+		return report, err
+	}
+	return report, nil
+}
+
+// shutdown 先排空进行中的请求，超时后强制退出
+func shutdown(srv Server, o *options) Report {
+	start := time.Now()
+	if o.health != nil {
+		o.health.Shutdown()
+	}
+	// 长连接流不会自行结束，需要在 GracefulStop 前关闭
+	if o.streams != nil {
+		o.streams.Close()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		srv.GracefulStop()
+	}()
+
+	timer := time.NewTimer(o.drainTimeout)
+	defer timer.Stop()
+
+	report := Report{Graceful: true}
+	select {
+	case <-done:
+	case <-timer.C:
+		report.Graceful = false
+		if o.tracker != nil {
+			report.AbortedUnary, report.AbortedStreams = o.tracker.Active()
+		}
+		srv.Stop()
+		<-done
+	}
+	report.Duration = time.Since(start)
+	return report
+}
@@ -0,0 +1,184 @@
+package lifecycle
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	pb "github.com/jergoo/go-grpc-tutorial/protos/ping" // 引入编译生成的包
+)
+
+// blockServer MultiPingPong 一直阻塞到客户端结束发送
+type blockServer struct {
+	pb.UnimplementedPingPongServer
+}
+
+func (s *blockServer) MultiPingPong(stream pb.PingPong_MultiPingPongServer) error {
+	for {
+		if _, err := stream.Recv(); err != nil {
+			return nil
+		}
+	}
+}
+
+func TestServe(t *testing.T) {
+	tests := []struct {
+		name         string
+		openStream   bool
+		wantGraceful bool
+		wantStreams  int
+	}{
+		{name: "idle", wantGraceful: true},
+		{name: "drain timeout", openStream: true, wantGraceful: false, wantStreams: 1},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			tracker := NewTracker()
+			srv := grpc.NewServer(grpc.StatsHandler(tracker))
+			pb.RegisterPingPongServer(srv, &blockServer{})
+			hs := health.NewServer()
+			healthpb.RegisterHealthServer(srv, hs)
+
+			lis := bufconn.Listen(1024 * 1024)
+			ctx, cancel := context.WithCancel(context.Background())
+			type result struct {
+				report Report
+				err    error
+			}
+			resc := make(chan result, 1)
+			go func() {
+				report, err := Serve(ctx, srv, lis, WithDrainTimeout(100*time.Millisecond), WithHealth(hs), WithTracker(tracker))
+				resc <- result{report, err}
+			}()
+
+			conn, err := grpc.Dial("bufnet",
+				grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+				grpc.WithTransportCredentials(insecure.NewCredentials()),
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer conn.Close()
+
+			var stream pb.PingPong_MultiPingPongClient
+			if tt.openStream {
+				stream, err = pb.NewPingPongClient(conn).MultiPingPong(context.Background())
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := stream.Send(&pb.PingRequest{Value: "ping"}); err != nil {
+					t.Fatal(err)
+				}
+				// 等待服务端开始处理
+				for {
+					if _, streams := tracker.Active(); streams == 1 {
+						break
+					}
+					time.Sleep(time.Millisecond)
+				}
+			}
+
+			cancel()
+			res := <-resc
+			if res.err != nil {
+				t.Fatal(res.err)
+			}
+			if res.report.Graceful != tt.wantGraceful {
+				t.Errorf("graceful = %t, want %t", res.report.Graceful, tt.wantGraceful)
+			}
+			if res.report.AbortedStreams != tt.wantStreams {
+				t.Errorf("aborted streams = %d, want %d", res.report.AbortedStreams, tt.wantStreams)
+			}
+			if stream != nil {
+				if _, err := stream.Recv(); status.Code(err) != codes.Unavailable {
+					t.Errorf("stream recv code = %v, want %v", status.Code(err), codes.Unavailable)
+				}
+			}
+
+			check, err := hs.Check(context.Background(), &healthpb.HealthCheckRequest{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if check.Status != healthpb.HealthCheckResponse_NOT_SERVING {
+				t.Errorf("health status = %v, want NOT_SERVING", check.Status)
+			}
+		})
+	}
+}
+
+func TestStreamCloser(t *testing.T) {
+	tracker := NewTracker(pb.PingPong_ServiceDesc.ServiceName)
+	closer := NewStreamCloser(healthpb.Health_Watch_FullMethodName)
+	srv := grpc.NewServer(grpc.StatsHandler(tracker), grpc.ChainStreamInterceptor(closer.StreamServerInterceptor()))
+	hs := health.NewServer()
+	healthpb.RegisterHealthServer(srv, hs)
+
+	lis := bufconn.Listen(1024 * 1024)
+	ctx, cancel := context.WithCancel(context.Background())
+	type result struct {
+		report Report
+		err    error
+	}
+	resc := make(chan result, 1)
+	go func() {
+		report, err := Serve(ctx, srv, lis, WithDrainTimeout(5*time.Second), WithHealth(hs), WithTracker(tracker), WithStreamCloser(closer))
+		resc <- result{report, err}
+	}()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	watch, err := healthpb.NewHealthClient(conn).Watch(context.Background(), &healthpb.HealthCheckRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := watch.Recv(); err != nil {
+		t.Fatal(err)
+	}
+	// 只统计 PingPong 服务的请求
+	if unary, streams := tracker.Active(); unary != 0 || streams != 0 {
+		t.Errorf("active = %d, %d, want 0, 0", unary, streams)
+	}
+
+	cancel()
+	res := <-resc
+	if res.err != nil {
+		t.Fatal(res.err)
+	}
+	if !res.report.Graceful || res.report.Duration >= time.Second {
+		t.Errorf("report = %v, want graceful before drain timeout", res.report)
+	}
+	for {
+		if _, err := watch.Recv(); err != nil {
+			if status.Code(err) != codes.Unavailable {
+				t.Errorf("watch code = %v, want %v", status.Code(err), codes.Unavailable)
+			}
+			break
+		}
+	}
+
+	// 关闭后新建的流直接结束
+	ss := &closerServerStream{ctx: context.Background()}
+	err = closer.StreamServerInterceptor()(nil, ss, &grpc.StreamServerInfo{FullMethod: healthpb.Health_Watch_FullMethodName},
+		func(interface{}, grpc.ServerStream) error { t.Error("handler called after Close"); return nil })
+	if status.Code(err) != codes.Unavailable {
+		t.Errorf("code after Close = %v, want %v", status.Code(err), codes.Unavailable)
+	}
+}
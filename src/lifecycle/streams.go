@@ -0,0 +1,118 @@
+package lifecycle
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// errShutdown 服务退出时结束长连接流返回的错误，客户端可据此重连其他实例
+var errShutdown = status.Error(codes.Unavailable, "server shutting down")
+
+// StreamCloser 记录长连接流，退出时在排空前结束这些流
+//
+// health Watch、反射等流在客户端断开前不会结束，不关闭时 GracefulStop 会一直等到排空超时。
+// 通过 grpc.ChainStreamInterceptor(closer.StreamServerInterceptor()) 注册到服务端。
+type StreamCloser struct {
+	methods map[string]bool
+	mu      sync.Mutex
+	closed  bool
+	cancels map[*context.CancelFunc]struct{}
+}
+
+// NewStreamCloser 创建 StreamCloser，methods 为需要关闭的完整方法名（/service/method），
+// 以 "/*" 结尾时匹配服务的所有方法，如 "/grpc.reflection.v1.ServerReflection/*"
+func NewStreamCloser(methods ...string) *StreamCloser {
+	c := &StreamCloser{
+		methods: make(map[string]bool, len(methods)),
+		cancels: make(map[*context.CancelFunc]struct{}),
+	}
+	for _, m := range methods {
+		c.methods[m] = true
+	}
+	return c
+}
+
+// match 判断完整方法名是否需要关闭
+func (c *StreamCloser) match(fullMethod string) bool {
+	if c.methods[fullMethod] {
+		return true
+	}
+	i := strings.LastIndex(fullMethod, "/")
+	return i > 0 && c.methods[fullMethod[:i]+"/*"]
+}
+
+// add 记录流的 cancel，已关闭时返回 false
+func (c *StreamCloser) add(cancel *context.CancelFunc) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return false
+	}
+	c.cancels[cancel] = struct{}{}
+	return true
+}
+
+func (c *StreamCloser) remove(cancel *context.CancelFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.cancels, cancel)
+}
+
+// Close 结束所有记录的流，之后新建的流直接返回 Unavailable
+func (c *StreamCloser) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	for cancel := range c.cancels {
+		(*cancel)()
+	}
+	c.cancels = nil
+}
+
+// StreamServerInterceptor 服务端流拦截器 - 记录匹配的流，Close 时结束
+//
+// 反射等处理方法阻塞在 Recv 上不会感知 context 取消，所以处理方法在单独的 goroutine 中运行，
+// Close 后拦截器直接返回；方法返回后流的 context 被取消，进行中的接收随之结束，goroutine 退出。
+func (c *StreamCloser) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if !c.match(info.FullMethod) {
+			return handler(srv, ss)
+		}
+		ctx, cancel := context.WithCancel(ss.Context())
+		defer cancel()
+		if !c.add(&cancel) {
+			return errShutdown
+		}
+		defer c.remove(&cancel)
+
+		errc := make(chan error, 1)
+		go func() {
+			errc <- handler(srv, &closerServerStream{ServerStream: ss, ctx: ctx})
+		}()
+		select {
+		case err := <-errc:
+			return err
+		case <-ctx.Done():
+		}
+		// 客户端取消时等待处理方法返回，服务退出时直接结束
+		if ss.Context().Err() != nil {
+			return <-errc
+		}
+		return errShutdown
+	}
+}
+
+// closerServerStream 包装 grpc.ServerStream，替换为可取消的 Context
+type closerServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *closerServerStream) Context() context.Context {
+	return s.ctx
+}
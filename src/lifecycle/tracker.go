@@ -0,0 +1,96 @@
+package lifecycle
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+
+	"google.golang.org/grpc/stats"
+)
+
+// Tracker 统计进行中的 RPC，实现 stats.Handler 接口
+//
+// 通过 grpc.StatsHandler(tracker) 注册到服务端，退出时据此报告被中断的请求数。
+type Tracker struct {
+	unary    int64
+	streams  int64
+	services map[string]bool
+}
+
+// NewTracker 创建 Tracker，只统计 services 中服务的请求（如 "protos.PingPong"），为空时统计全部请求
+//
+// health Watch、反射等长连接流不属于业务请求，不应计入被中断的请求数。
+func NewTracker(services ...string) *Tracker {
+	t := &Tracker{}
+	if len(services) > 0 {
+		t.services = make(map[string]bool, len(services))
+		for _, s := range services {
+			t.services[s] = true
+		}
+	}
+	return t
+}
+
+// Active 返回进行中的单次请求数和流数
+func (t *Tracker) Active() (unary, streams int) {
+	return int(atomic.LoadInt64(&t.unary)), int(atomic.LoadInt64(&t.streams))
+}
+
+// rpcKey context 中保存请求类型的 key
+type rpcKey struct{}
+
+// rpcInfo 单个请求的类型，Begin 时写入，End 时读取
+type rpcInfo struct {
+	begun  bool
+	stream bool
+}
+
+// TagRPC 实现 stats.Handler
+func (t *Tracker) TagRPC(ctx context.Context, info *stats.RPCTagInfo) context.Context {
+	if !t.tracked(info.FullMethodName) {
+		return ctx
+	}
+	return context.WithValue(ctx, rpcKey{}, &rpcInfo{})
+}
+
+// HandleRPC 实现 stats.Handler，请求开始时计数加一，结束时减一
+func (t *Tracker) HandleRPC(ctx context.Context, s stats.RPCStats) {
+	info, ok := ctx.Value(rpcKey{}).(*rpcInfo)
+	if !ok {
+		return
+	}
+	switch s := s.(type) {
+	case *stats.Begin:
+		info.begun = true
+		info.stream = s.IsClientStream || s.IsServerStream
+		atomic.AddInt64(t.counter(info.stream), 1)
+	case *stats.End:
+		if info.begun {
+			atomic.AddInt64(t.counter(info.stream), -1)
+		}
+	}
+}
+
+// TagConn 实现 stats.Handler
+func (t *Tracker) TagConn(ctx context.Context, info *stats.ConnTagInfo) context.Context {
+	return ctx
+}
+
+// HandleConn 实现 stats.Handler
+func (t *Tracker) HandleConn(ctx context.Context, s stats.ConnStats) {}
+
+func (t *Tracker) counter(stream bool) *int64 {
+	if stream {
+		return &t.streams
+	}
+	return &t.unary
+}
+
+// tracked 判断完整方法名（/service/method）是否需要统计
+func (t *Tracker) tracked(fullMethod string) bool {
+	if t.services == nil {
+		return true
+	}
+	service, _, _ := strings.Cut(strings.TrimPrefix(fullMethod, "/"), "/")
+	return t.services[service]
+}
@@ -0,0 +1,364 @@
+// Package logging 基于 log/slog 的结构化日志拦截器
+//
+// 每次调用结束时输出一条日志，包含方法、对端地址、状态码、耗时和请求 ID，
+// 流调用另外记录收发的消息数量和字节数。日志级别根据状态码决定，可以选择记录请求和响应内容。
+package logging
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/jergoo/go-grpc-tutorial/middleware"
+	"github.com/jergoo/go-grpc-tutorial/requestid"
+)
+
+// 日志字段
+const (
+	KeyKind      = "grpc.kind"
+	KeyMethod    = "grpc.method"
+	KeyPeer      = "peer.address"
+	KeyCode      = "grpc.code"
+	KeyError     = "grpc.error"
+	KeyDuration  = "grpc.duration_ms"
+	KeyRequestID = "request_id"
+	KeyRequest   = "grpc.request"
+	KeyResponse  = "grpc.response"
+	KeyRecvMsgs  = "grpc.recv_msgs"
+	KeySentMsgs  = "grpc.sent_msgs"
+	KeyRecvBytes = "grpc.recv_bytes"
+	KeySentBytes = "grpc.sent_bytes"
+	KeyDirection = "grpc.direction"
+	KeyPayload   = "grpc.payload"
+)
+
+// errPanic 处理方法 panic 时记录的错误，与外层 recovery 拦截器返回的状态码一致
+var errPanic = status.Error(codes.Internal, "panic")
+
+// options 日志配置
+type options struct {
+	logger    *slog.Logger
+	payloads  bool
+	levelFunc func(codes.Code) slog.Level
+}
+
+// Option 日志配置项
+type Option func(*options)
+
+// WithLogger 指定 logger，默认输出 JSON 格式到标准错误
+func WithLogger(l *slog.Logger) Option {
+	return func(o *options) {
+		o.logger = l
+	}
+}
+
+// WithPayloads 记录请求和响应内容
+//
+// 单次调用的请求和响应记录在调用日志中，流调用的每条消息单独记录一条 Debug 级别的日志。
+func WithPayloads() Option {
+	return func(o *options) {
+		o.payloads = true
+	}
+}
+
+// WithLevelFunc 指定状态码对应的日志级别，默认为 DefaultLevel
+func WithLevelFunc(f func(codes.Code) slog.Level) Option {
+	return func(o *options) {
+		o.levelFunc = f
+	}
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		logger:    slog.New(slog.NewJSONHandler(os.Stderr, nil)),
+		levelFunc: DefaultLevel,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// DefaultLevel 默认日志级别：成功为 Info，调用方错误为 Warn，服务端错误为 Error
+func DefaultLevel(code codes.Code) slog.Level {
+	switch code {
+	case codes.OK:
+		return slog.LevelInfo
+	case codes.Canceled, codes.InvalidArgument, codes.NotFound, codes.AlreadyExists,
+		codes.PermissionDenied, codes.Unauthenticated, codes.ResourceExhausted,
+		codes.FailedPrecondition, codes.Aborted, codes.OutOfRange, codes.DeadlineExceeded:
+		return slog.LevelWarn
+	default:
+		return slog.LevelError
+	}
+}
+
+// Middleware 服务端和客户端日志拦截器，用于 middleware.Registry
+func Middleware(opts ...Option) middleware.Middleware {
+	return middleware.Middleware{
+		UnaryServer:  UnaryServerInterceptor(opts...),
+		StreamServer: StreamServerInterceptor(opts...),
+		UnaryClient:  UnaryClientInterceptor(opts...),
+		StreamClient: StreamClientInterceptor(opts...),
+	}
+}
+
+// call 一次调用的日志信息
+type call struct {
+	o         *options
+	kind      string
+	method    string
+	peer      string
+	requestID string
+	start     time.Time
+
+	stream                                   bool
+	recvMsgs, sentMsgs, recvBytes, sentBytes atomic.Int64
+	once                                     sync.Once
+}
+
+func newCall(o *options, kind, method, peer, requestID string, stream bool) *call {
+	return &call{o: o, kind: kind, method: method, peer: peer, requestID: requestID, stream: stream, start: time.Now()}
+}
+
+// finish 输出调用日志，流调用只输出一次
+func (c *call) finish(ctx context.Context, err error, attrs ...slog.Attr) {
+	c.once.Do(func() {
+		code := status.Code(err)
+		attrs = append([]slog.Attr{
+			slog.String(KeyKind, c.kind),
+			slog.String(KeyMethod, c.method),
+			slog.String(KeyPeer, c.peer),
+			slog.String(KeyRequestID, c.requestID),
+			slog.String(KeyCode, code.String()),
+			slog.Float64(KeyDuration, float64(time.Since(c.start).Microseconds())/1000),
+		}, attrs...)
+		if err != nil {
+			attrs = append(attrs, slog.String(KeyError, status.Convert(err).Message()))
+		}
+		if c.stream {
+			attrs = append(attrs,
+				slog.Int64(KeyRecvMsgs, c.recvMsgs.Load()),
+				slog.Int64(KeySentMsgs, c.sentMsgs.Load()),
+				slog.Int64(KeyRecvBytes, c.recvBytes.Load()),
+				slog.Int64(KeySentBytes, c.sentBytes.Load()),
+			)
+		}
+		c.o.logger.LogAttrs(ctx, c.o.levelFunc(code), "finished call", attrs...)
+	})
+}
+
+// message 记录一条流消息
+func (c *call) message(ctx context.Context, direction string, m interface{}) {
+	size := int64(messageSize(m))
+	if direction == "send" {
+		c.sentMsgs.Add(1)
+		c.sentBytes.Add(size)
+	} else {
+		c.recvMsgs.Add(1)
+		c.recvBytes.Add(size)
+	}
+	if c.o.payloads {
+		c.o.logger.LogAttrs(ctx, slog.LevelDebug, "stream message",
+			slog.String(KeyKind, c.kind),
+			slog.String(KeyMethod, c.method),
+			slog.String(KeyRequestID, c.requestID),
+			slog.String(KeyDirection, direction),
+			slog.Any(KeyPayload, payload(m)),
+		)
+	}
+}
+
+func messageSize(m interface{}) int {
+	if pm, ok := m.(proto.Message); ok {
+		return proto.Size(pm)
+	}
+	return 0
+}
+
+// payload 将消息转换为 JSON，JSON handler 直接输出，其他 handler 输出 JSON 字符串
+func payload(m interface{}) slog.Value {
+	pm, ok := m.(proto.Message)
+	if !ok {
+		return slog.StringValue(fmt.Sprintf("%v", m))
+	}
+	data, err := protojson.Marshal(pm)
+	if err != nil {
+		return slog.StringValue(err.Error())
+	}
+	return slog.AnyValue(json.RawMessage(data))
+}
+
+func peerAddr(ctx context.Context) string {
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		return p.Addr.String()
+	}
+	return ""
+}
+
+// UnaryServerInterceptor 服务端拦截器 - 记录调用日志，并通过响应 header 返回请求 ID
+func UnaryServerInterceptor(opts ...Option) grpc.UnaryServerInterceptor {
+	o := newOptions(opts)
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, id := requestid.Incoming(ctx)
+		grpc.SetHeader(ctx, metadata.Pairs(requestid.Key, id))
+		c := newCall(o, "server", info.FullMethod, peerAddr(ctx), id, false)
+		// handler panic 时同样结束记录，panic 继续向外传递给 recovery 拦截器
+		done := false
+		defer func() {
+			if !done {
+				c.finish(ctx, errPanic)
+			}
+		}()
+
+		resp, err := handler(ctx, req)
+		done = true
+
+		var attrs []slog.Attr
+		if o.payloads {
+			attrs = append(attrs, slog.Any(KeyRequest, payload(req)))
+			if err == nil {
+				attrs = append(attrs, slog.Any(KeyResponse, payload(resp)))
+			}
+		}
+		c.finish(ctx, err, attrs...)
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor 服务端流拦截器 - 记录调用日志和收发的消息数量
+func StreamServerInterceptor(opts ...Option) grpc.StreamServerInterceptor {
+	o := newOptions(opts)
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, id := requestid.Incoming(ss.Context())
+		ss.SetHeader(metadata.Pairs(requestid.Key, id))
+		c := newCall(o, "server", info.FullMethod, peerAddr(ctx), id, true)
+
+		// handler panic 时同样结束记录，panic 继续向外传递给 recovery 拦截器
+		done := false
+		defer func() {
+			if !done {
+				c.finish(ctx, errPanic)
+			}
+		}()
+		err := handler(srv, &loggingServerStream{ServerStream: ss, ctx: ctx, call: c})
+		done = true
+		c.finish(ctx, err)
+		return err
+	}
+}
+
+// loggingServerStream 包装 grpc.ServerStream，记录收发的消息
+type loggingServerStream struct {
+	grpc.ServerStream
+	ctx  context.Context
+	call *call
+}
+
+func (s *loggingServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *loggingServerStream) SendMsg(m interface{}) error {
+	err := s.ServerStream.SendMsg(m)
+	if err == nil {
+		s.call.message(s.ctx, "send", m)
+	}
+	return err
+}
+
+func (s *loggingServerStream) RecvMsg(m interface{}) error {
+	err := s.ServerStream.RecvMsg(m)
+	if err == nil {
+		s.call.message(s.ctx, "recv", m)
+	}
+	return err
+}
+
+// UnaryClientInterceptor 客户端拦截器 - 记录调用日志，并通过请求 metadata 传递请求 ID
+func UnaryClientInterceptor(opts ...Option) grpc.UnaryClientInterceptor {
+	o := newOptions(opts)
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		ctx, id := requestid.Outgoing(ctx)
+		c := newCall(o, "client", method, cc.Target(), id, false)
+
+		err := invoker(ctx, method, req, reply, cc, opts...)
+
+		var attrs []slog.Attr
+		if o.payloads {
+			attrs = append(attrs, slog.Any(KeyRequest, payload(req)))
+			if err == nil {
+				attrs = append(attrs, slog.Any(KeyResponse, payload(reply)))
+			}
+		}
+		c.finish(ctx, err, attrs...)
+		return err
+	}
+}
+
+// StreamClientInterceptor 客户端流拦截器 - 记录调用日志和收发的消息数量
+//
+// 调用日志在接收到流结束或错误时输出，调用方没有读取到流结束时不会输出。
+func StreamClientInterceptor(opts ...Option) grpc.StreamClientInterceptor {
+	o := newOptions(opts)
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		ctx, id := requestid.Outgoing(ctx)
+		c := newCall(o, "client", method, cc.Target(), id, true)
+
+		cs, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			c.finish(ctx, err)
+			return nil, err
+		}
+		return &loggingClientStream{ClientStream: cs, ctx: ctx, desc: desc, call: c}, nil
+	}
+}
+
+// loggingClientStream 包装 grpc.ClientStream，记录收发的消息
+type loggingClientStream struct {
+	grpc.ClientStream
+	ctx  context.Context
+	desc *grpc.StreamDesc
+	call *call
+}
+
+func (s *loggingClientStream) SendMsg(m interface{}) error {
+	err := s.ClientStream.SendMsg(m)
+	if err == nil {
+		s.call.message(s.ctx, "send", m)
+	} else if err != io.EOF {
+		s.call.finish(s.ctx, err)
+	}
+	return err
+}
+
+func (s *loggingClientStream) RecvMsg(m interface{}) error {
+	err := s.ClientStream.RecvMsg(m)
+	switch {
+	case err == io.EOF:
+		s.call.finish(s.ctx, nil)
+	case err != nil:
+		s.call.finish(s.ctx, err)
+	default:
+		s.call.message(s.ctx, "recv", m)
+		// 服务端非流式响应只有一条消息，收到后调用结束
+		if !s.desc.ServerStreams {
+			s.call.finish(s.ctx, nil)
+		}
+	}
+	return err
+}
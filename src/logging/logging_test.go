@@ -0,0 +1,237 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/jergoo/go-grpc-tutorial/pingpong"
+	"github.com/jergoo/go-grpc-tutorial/pingtest"
+	pb "github.com/jergoo/go-grpc-tutorial/protos/ping" // 引入编译生成的包
+	"github.com/jergoo/go-grpc-tutorial/requestid"
+)
+
+// syncBuffer 并发安全的日志输出
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+// records 按 grpc.kind 返回调用日志
+func (b *syncBuffer) records(t *testing.T) map[string]map[string]interface{} {
+	t.Helper()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	out := map[string]map[string]interface{}{}
+	dec := json.NewDecoder(&b.buf)
+	for {
+		var r map[string]interface{}
+		if err := dec.Decode(&r); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		if r["msg"] == "finished call" {
+			out[r[KeyKind].(string)] = r
+		}
+	}
+	return out
+}
+
+func newTestServer(t *testing.T, impl pb.PingPongServer, opts ...Option) (*pingtest.Server, *syncBuffer) {
+	buf := &syncBuffer{}
+	logger := slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	opts = append([]Option{WithLogger(logger)}, opts...)
+	srv := pingtest.NewServer(t, impl,
+		pingtest.WithServerOptions(
+			grpc.UnaryInterceptor(UnaryServerInterceptor(opts...)),
+			grpc.StreamInterceptor(StreamServerInterceptor(opts...)),
+		),
+		pingtest.WithDialOptions(
+			grpc.WithUnaryInterceptor(UnaryClientInterceptor(opts...)),
+			grpc.WithStreamInterceptor(StreamClientInterceptor(opts...)),
+		),
+	)
+	return srv, buf
+}
+
+func TestUnary(t *testing.T) {
+	tests := []struct {
+		name      string
+		impl      pb.PingPongServer
+		requestID string
+		code      string
+		level     string
+	}{
+		{name: "ok", impl: pingpong.NewPingPongServer(), code: "OK", level: "INFO"},
+		{name: "request id", impl: pingpong.NewPingPongServer(), requestID: "abc", code: "OK", level: "INFO"},
+		{name: "unimplemented", impl: &pb.UnimplementedPingPongServer{}, code: "Unimplemented", level: "ERROR"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			srv, buf := newTestServer(t, tt.impl)
+			ctx := context.Background()
+			if tt.requestID != "" {
+				ctx = metadata.AppendToOutgoingContext(ctx, requestid.Key, tt.requestID)
+			}
+			var header metadata.MD
+			srv.Client.Ping(ctx, &pb.PingRequest{Value: "ping"}, grpc.Header(&header))
+
+			records := buf.records(t)
+			server, client := records["server"], records["client"]
+			if server == nil || client == nil {
+				t.Fatalf("records = %v", records)
+			}
+			for _, r := range []map[string]interface{}{server, client} {
+				if r[KeyMethod] != "/protos.PingPong/Ping" || r[KeyCode] != tt.code || r["level"] != tt.level {
+					t.Errorf("record = %v", r)
+				}
+				if _, ok := r[KeyDuration].(float64); !ok {
+					t.Errorf("duration missing: %v", r)
+				}
+				if _, ok := r[KeyRecvMsgs]; ok {
+					t.Errorf("unary record has stream fields: %v", r)
+				}
+			}
+			if server[KeyRequestID] != client[KeyRequestID] || server[KeyRequestID] == "" {
+				t.Errorf("request id server = %v, client = %v", server[KeyRequestID], client[KeyRequestID])
+			}
+			if tt.requestID != "" && server[KeyRequestID] != tt.requestID {
+				t.Errorf("request id = %v, want %s", server[KeyRequestID], tt.requestID)
+			}
+			if tt.code == "OK" {
+				if got := header.Get(requestid.Key); len(got) != 1 || got[0] != server[KeyRequestID] {
+					t.Errorf("header request id = %v", got)
+				}
+			}
+			if server[KeyPeer] == "" || client[KeyPeer] != pingtest.Target {
+				t.Errorf("peer server = %v, client = %v", server[KeyPeer], client[KeyPeer])
+			}
+		})
+	}
+}
+
+func TestStream(t *testing.T) {
+	srv, buf := newTestServer(t, pingpong.NewPingPongServer(pingpong.WithPongCount(3)))
+	ctx := context.Background()
+
+	tests := []struct {
+		name       string
+		call       func() error
+		method     string
+		serverRecv float64
+		serverSent float64
+	}{
+		{
+			name:   "multi pong",
+			method: "/protos.PingPong/MultiPong",
+			call: func() error {
+				stream, err := srv.Client.MultiPong(ctx, &pb.PingRequest{Value: "ping"})
+				if err != nil {
+					return err
+				}
+				for {
+					if _, err := stream.Recv(); err == io.EOF {
+						return nil
+					} else if err != nil {
+						return err
+					}
+				}
+			},
+			serverRecv: 1, serverSent: 3,
+		},
+		{
+			name:   "multi ping",
+			method: "/protos.PingPong/MultiPing",
+			call: func() error {
+				stream, err := srv.Client.MultiPing(ctx)
+				if err != nil {
+					return err
+				}
+				for i := 0; i < 2; i++ {
+					if err := stream.Send(&pb.PingRequest{Value: "ping"}); err != nil {
+						return err
+					}
+				}
+				_, err = stream.CloseAndRecv()
+				return err
+			},
+			serverRecv: 2, serverSent: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.call(); err != nil {
+				t.Fatal(err)
+			}
+			records := buf.records(t)
+			server, client := records["server"], records["client"]
+			if server == nil || client == nil {
+				t.Fatalf("records = %v", records)
+			}
+			if server[KeyMethod] != tt.method || server[KeyCode] != "OK" {
+				t.Errorf("server record = %v", server)
+			}
+			if server[KeyRecvMsgs] != tt.serverRecv || server[KeySentMsgs] != tt.serverSent {
+				t.Errorf("server msgs recv = %v, sent = %v", server[KeyRecvMsgs], server[KeySentMsgs])
+			}
+			// 客户端和服务端收发的消息相反
+			if client[KeySentMsgs] != tt.serverRecv || client[KeyRecvMsgs] != tt.serverSent {
+				t.Errorf("client msgs recv = %v, sent = %v", client[KeyRecvMsgs], client[KeySentMsgs])
+			}
+			if server[KeySentBytes] != client[KeyRecvBytes] || server[KeySentBytes].(float64) <= 0 {
+				t.Errorf("bytes server sent = %v, client recv = %v", server[KeySentBytes], client[KeyRecvBytes])
+			}
+		})
+	}
+}
+
+func TestPayloads(t *testing.T) {
+	srv, buf := newTestServer(t, pingpong.NewPingPongServer(), WithPayloads())
+	if _, err := srv.Client.Ping(context.Background(), &pb.PingRequest{Value: "ping"}); err != nil {
+		t.Fatal(err)
+	}
+	server := buf.records(t)["server"]
+	req, _ := server[KeyRequest].(map[string]interface{})
+	resp, _ := server[KeyResponse].(map[string]interface{})
+	if req["value"] != "ping" || resp["value"] != "pong" {
+		t.Errorf("request = %v, response = %v", server[KeyRequest], server[KeyResponse])
+	}
+}
+
+func TestDefaultLevel(t *testing.T) {
+	tests := []struct {
+		code codes.Code
+		want slog.Level
+	}{
+		{code: codes.OK, want: slog.LevelInfo},
+		{code: codes.InvalidArgument, want: slog.LevelWarn},
+		{code: codes.Unauthenticated, want: slog.LevelWarn},
+		{code: codes.DeadlineExceeded, want: slog.LevelWarn},
+		{code: codes.Internal, want: slog.LevelError},
+		{code: codes.Unavailable, want: slog.LevelError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.code.String(), func(t *testing.T) {
+			if got := DefaultLevel(tt.code); got != tt.want {
+				t.Errorf("level = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
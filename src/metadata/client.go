@@ -14,10 +14,10 @@ import (
 )
 
 // Ping 单次请求-响应模式
-func Ping() {
-	conn, err := grpc.Dial("localhost:1234", grpc.WithInsecure())
+func Ping(target string, opts ...grpc.DialOption) error {
+	conn, err := grpc.Dial(target, append([]grpc.DialOption{grpc.WithInsecure()}, opts...)...)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 	defer conn.Close()
 
@@ -40,18 +40,19 @@ func Ping() {
 	var reponseMD metadata.MD
 	res, err := client.Ping(ctx, &pb.PingRequest{Value: "ping"}, grpc.Header(&reponseMD))
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 
 	log.Printf("Got response md: %v", reponseMD)
 	log.Println(res.Value)
+	return nil
 }
 
 // MultiPong 服务端流模式
-func MultiPong() {
-	conn, err := grpc.Dial("localhost:1234", grpc.WithInsecure())
+func MultiPong(target string, opts ...grpc.DialOption) error {
+	conn, err := grpc.Dial(target, append([]grpc.DialOption{grpc.WithInsecure()}, opts...)...)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 	defer conn.Close()
 
@@ -64,13 +65,13 @@ func MultiPong() {
 	// 获得对 stream 对象的引用
 	stream, err := client.MultiPong(ctx, &pb.PingRequest{Value: "ping"})
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 
 	// 读取响应metadata
 	mdResponse, err := stream.Header()
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 	log.Printf("get response md: %v", mdResponse)
 
@@ -81,8 +82,9 @@ func MultiPong() {
 			if err == io.EOF {
 				break
 			}
-			log.Fatal(err)
+			return err
 		}
 		log.Println(msg.Value)
 	}
+	return nil
 }
@@ -2,6 +2,9 @@ package main
 
 import (
 	"testing"
+
+	"github.com/jergoo/go-grpc-tutorial/pingpong"
+	"github.com/jergoo/go-grpc-tutorial/pingtest"
 )
 
 func TestPing(t *testing.T) {
@@ -12,7 +15,11 @@ func TestPing(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			Ping()
+			t.Parallel()
+			srv := pingtest.NewServer(t, pingpong.NewPingPongServer(pingPongOptions...))
+			if err := Ping(pingtest.Target, srv.DialOption()); err != nil {
+				t.Fatal(err)
+			}
 		})
 	}
 }
@@ -25,7 +32,11 @@ func TestMultiPong(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			MultiPong()
+			t.Parallel()
+			srv := pingtest.NewServer(t, pingpong.NewPingPongServer(pingPongOptions...))
+			if err := MultiPong(pingtest.Target, srv.DialOption()); err != nil {
+				t.Fatal(err)
+			}
 		})
 	}
 }
@@ -0,0 +1,384 @@
+// Package metrics gRPC 服务端和客户端的 Prometheus 监控指标
+//
+// 拦截器记录调用开始和结束次数、耗时分布、正在处理的调用数以及流调用收发的消息数，
+// 指标按 grpc_type（unary/client_stream/server_stream/bidi_stream）、grpc_service、grpc_method 和 grpc_code 区分。
+package metrics
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/jergoo/go-grpc-tutorial/middleware"
+)
+
+// 调用类型
+const (
+	Unary        = "unary"
+	ClientStream = "client_stream"
+	ServerStream = "server_stream"
+	BidiStream   = "bidi_stream"
+)
+
+// DefaultBuckets 默认耗时分布区间，单位秒
+var DefaultBuckets = []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// errPanic 处理方法 panic 时记录的错误，与外层 recovery 拦截器返回的状态码一致
+var errPanic = status.Error(codes.Internal, "panic")
+
+// options 指标配置
+type options struct {
+	namespace string
+	buckets   []float64
+}
+
+// Option 指标配置项
+type Option func(*options)
+
+// WithNamespace 指定指标名称前缀，如 tutorial 对应 tutorial_grpc_server_started_total
+func WithNamespace(ns string) Option {
+	return func(o *options) {
+		o.namespace = ns
+	}
+}
+
+// WithBuckets 指定耗时分布区间，默认 DefaultBuckets
+func WithBuckets(buckets ...float64) Option {
+	return func(o *options) {
+		o.buckets = buckets
+	}
+}
+
+// metrics 服务端和客户端共用的指标
+type metrics struct {
+	namespace string
+	started   *prometheus.CounterVec
+	handled   *prometheus.CounterVec
+	latency   *prometheus.HistogramVec
+	inFlight  *prometheus.GaugeVec
+	msgRecv   *prometheus.CounterVec
+	msgSent   *prometheus.CounterVec
+}
+
+// newMetrics 创建指标，side 为 server 或 client
+func newMetrics(side string, opts []Option) *metrics {
+	o := &options{buckets: DefaultBuckets}
+	for _, opt := range opts {
+		opt(o)
+	}
+	labels := []string{"grpc_type", "grpc_service", "grpc_method"}
+	codeLabels := append(append([]string(nil), labels...), "grpc_code")
+	subsystem := "grpc_" + side
+	return &metrics{
+		namespace: o.namespace,
+		started: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: o.namespace, Subsystem: subsystem, Name: "started_total",
+			Help: "Total number of RPCs started.",
+		}, labels),
+		handled: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: o.namespace, Subsystem: subsystem, Name: "handled_total",
+			Help: "Total number of RPCs completed, regardless of success or failure.",
+		}, codeLabels),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: o.namespace, Subsystem: subsystem, Name: "handling_seconds",
+			Help:    "Histogram of RPC handling latency in seconds.",
+			Buckets: o.buckets,
+		}, codeLabels),
+		inFlight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: o.namespace, Subsystem: subsystem, Name: "in_flight",
+			Help: "Number of RPCs currently in flight.",
+		}, labels),
+		msgRecv: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: o.namespace, Subsystem: subsystem, Name: "msg_received_total",
+			Help: "Total number of stream messages received.",
+		}, labels),
+		msgSent: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: o.namespace, Subsystem: subsystem, Name: "msg_sent_total",
+			Help: "Total number of stream messages sent.",
+		}, labels),
+	}
+}
+
+func (m *metrics) collectors() []prometheus.Collector {
+	return []prometheus.Collector{m.started, m.handled, m.latency, m.inFlight, m.msgRecv, m.msgSent}
+}
+
+// Describe 实现 prometheus.Collector
+func (m *metrics) Describe(ch chan<- *prometheus.Desc) {
+	for _, c := range m.collectors() {
+		c.Describe(ch)
+	}
+}
+
+// Collect 实现 prometheus.Collector
+func (m *metrics) Collect(ch chan<- prometheus.Metric) {
+	for _, c := range m.collectors() {
+		c.Collect(ch)
+	}
+}
+
+// rpc 一次调用的指标记录
+type rpc struct {
+	m      *metrics
+	labels []string
+	start  time.Time
+	once   sync.Once
+}
+
+// begin 记录调用开始
+func (m *metrics) begin(typ, fullMethod string) *rpc {
+	service, method := splitMethod(fullMethod)
+	r := &rpc{m: m, labels: []string{typ, service, method}, start: time.Now()}
+	m.started.WithLabelValues(r.labels...).Inc()
+	m.inFlight.WithLabelValues(r.labels...).Inc()
+	return r
+}
+
+// end 记录调用结束，只记录一次
+func (r *rpc) end(err error) {
+	r.once.Do(func() {
+		labels := append(append([]string(nil), r.labels...), status.Code(err).String())
+		r.m.inFlight.WithLabelValues(r.labels...).Dec()
+		r.m.handled.WithLabelValues(labels...).Inc()
+		r.m.latency.WithLabelValues(labels...).Observe(time.Since(r.start).Seconds())
+	})
+}
+
+func (r *rpc) recv() {
+	r.m.msgRecv.WithLabelValues(r.labels...).Inc()
+}
+
+func (r *rpc) sent() {
+	r.m.msgSent.WithLabelValues(r.labels...).Inc()
+}
+
+// splitMethod 拆分完整方法名 /package.service/method
+func splitMethod(fullMethod string) (string, string) {
+	fullMethod = strings.TrimPrefix(fullMethod, "/")
+	if i := strings.LastIndex(fullMethod, "/"); i >= 0 {
+		return fullMethod[:i], fullMethod[i+1:]
+	}
+	return "unknown", fullMethod
+}
+
+func streamType(clientStream, serverStream bool) string {
+	switch {
+	case clientStream && serverStream:
+		return BidiStream
+	case clientStream:
+		return ClientStream
+	case serverStream:
+		return ServerStream
+	default:
+		return Unary
+	}
+}
+
+// ServerMetrics 服务端指标
+type ServerMetrics struct {
+	*metrics
+	panics *prometheus.CounterVec
+}
+
+// NewServerMetrics 创建服务端指标，需要注册到 prometheus.Registerer 后才能导出
+func NewServerMetrics(opts ...Option) *ServerMetrics {
+	m := &ServerMetrics{metrics: newMetrics("server", opts)}
+	m.panics = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: m.namespace, Subsystem: "grpc_server", Name: "panics_recovered_total",
+		Help: "Total number of panics recovered in RPC handlers.",
+	}, []string{"grpc_service", "grpc_method"})
+	return m
+}
+
+// Describe 实现 prometheus.Collector
+func (m *ServerMetrics) Describe(ch chan<- *prometheus.Desc) {
+	m.metrics.Describe(ch)
+	m.panics.Describe(ch)
+}
+
+// Collect 实现 prometheus.Collector
+func (m *ServerMetrics) Collect(ch chan<- prometheus.Metric) {
+	m.metrics.Collect(ch)
+	m.panics.Collect(ch)
+}
+
+// PanicRecovered 记录一次服务端处理 panic，由 recovery 拦截器调用
+func (m *ServerMetrics) PanicRecovered(fullMethod string) {
+	service, method := splitMethod(fullMethod)
+	m.panics.WithLabelValues(service, method).Inc()
+}
+
+// InitializeMetrics 使用服务端已注册的方法初始化指标，没有调用的方法也会导出值为 0 的指标
+func (m *ServerMetrics) InitializeMetrics(srv *grpc.Server) {
+	for service, info := range srv.GetServiceInfo() {
+		for _, method := range info.Methods {
+			labels := []string{streamType(method.IsClientStream, method.IsServerStream), service, method.Name}
+			m.started.WithLabelValues(labels...)
+			m.inFlight.WithLabelValues(labels...)
+			m.msgRecv.WithLabelValues(labels...)
+			m.msgSent.WithLabelValues(labels...)
+		}
+	}
+}
+
+// UnaryServerInterceptor 服务端拦截器 - 记录调用指标
+func (m *ServerMetrics) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		r := m.begin(Unary, info.FullMethod)
+		// handler panic 时同样结束记录，panic 继续向外传递给 recovery 拦截器
+		done := false
+		defer func() {
+			if !done {
+				r.end(errPanic)
+			}
+		}()
+		resp, err := handler(ctx, req)
+		done = true
+		r.end(err)
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor 服务端流拦截器 - 记录调用指标和收发的消息数
+func (m *ServerMetrics) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		r := m.begin(streamType(info.IsClientStream, info.IsServerStream), info.FullMethod)
+		// handler panic 时同样结束记录，panic 继续向外传递给 recovery 拦截器
+		done := false
+		defer func() {
+			if !done {
+				r.end(errPanic)
+			}
+		}()
+		err := handler(srv, &metricsServerStream{ServerStream: ss, rpc: r})
+		done = true
+		r.end(err)
+		return err
+	}
+}
+
+// metricsServerStream 包装 grpc.ServerStream，记录收发的消息数
+type metricsServerStream struct {
+	grpc.ServerStream
+	rpc *rpc
+}
+
+func (s *metricsServerStream) SendMsg(m interface{}) error {
+	err := s.ServerStream.SendMsg(m)
+	if err == nil {
+		s.rpc.sent()
+	}
+	return err
+}
+
+func (s *metricsServerStream) RecvMsg(m interface{}) error {
+	err := s.ServerStream.RecvMsg(m)
+	if err == nil {
+		s.rpc.recv()
+	}
+	return err
+}
+
+// ClientMetrics 客户端指标
+type ClientMetrics struct {
+	*metrics
+}
+
+// NewClientMetrics 创建客户端指标，需要注册到 prometheus.Registerer 后才能导出
+func NewClientMetrics(opts ...Option) *ClientMetrics {
+	return &ClientMetrics{newMetrics("client", opts)}
+}
+
+// UnaryClientInterceptor 客户端拦截器 - 记录调用指标
+func (m *ClientMetrics) UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		r := m.begin(Unary, method)
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		r.end(err)
+		return err
+	}
+}
+
+// StreamClientInterceptor 客户端流拦截器 - 记录调用指标和收发的消息数
+//
+// 调用在接收到流结束或错误时结束，调用方没有读取到流结束时一直计入 in_flight。
+func (m *ClientMetrics) StreamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		r := m.begin(streamType(desc.ClientStreams, desc.ServerStreams), method)
+		cs, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			r.end(err)
+			return nil, err
+		}
+		return &metricsClientStream{ClientStream: cs, desc: desc, rpc: r}, nil
+	}
+}
+
+// metricsClientStream 包装 grpc.ClientStream，记录收发的消息数
+type metricsClientStream struct {
+	grpc.ClientStream
+	desc *grpc.StreamDesc
+	rpc  *rpc
+}
+
+func (s *metricsClientStream) SendMsg(m interface{}) error {
+	err := s.ClientStream.SendMsg(m)
+	if err == nil {
+		s.rpc.sent()
+	} else if err != io.EOF {
+		s.rpc.end(err)
+	}
+	return err
+}
+
+func (s *metricsClientStream) RecvMsg(m interface{}) error {
+	err := s.ClientStream.RecvMsg(m)
+	switch {
+	case err == io.EOF:
+		s.rpc.end(nil)
+	case err != nil:
+		s.rpc.end(err)
+	default:
+		s.rpc.recv()
+		// 服务端非流式响应只有一条消息，收到后调用结束
+		if !s.desc.ServerStreams {
+			s.rpc.end(nil)
+		}
+	}
+	return err
+}
+
+// Middleware 服务端和客户端指标拦截器，用于 middleware.Registry，server 或 client 为 nil 时跳过对应的拦截器
+func Middleware(server *ServerMetrics, client *ClientMetrics) middleware.Middleware {
+	var m middleware.Middleware
+	if server != nil {
+		m.UnaryServer = server.UnaryServerInterceptor()
+		m.StreamServer = server.StreamServerInterceptor()
+	}
+	if client != nil {
+		m.UnaryClient = client.UnaryClientInterceptor()
+		m.StreamClient = client.StreamClientInterceptor()
+	}
+	return m
+}
+
+// Handler 导出 gatherer 中指标的 HTTP handler
+func Handler(gatherer prometheus.Gatherer) http.Handler {
+	return promhttp.HandlerFor(gatherer, promhttp.HandlerOpts{})
+}
+
+// NewMux 返回在 /metrics 导出 gatherer 中指标的 http.ServeMux
+func NewMux(gatherer prometheus.Gatherer) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", Handler(gatherer))
+	return mux
+}
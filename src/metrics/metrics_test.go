@@ -0,0 +1,185 @@
+package metrics
+
+import (
+	"context"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"google.golang.org/grpc"
+
+	"github.com/jergoo/go-grpc-tutorial/pingpong"
+	"github.com/jergoo/go-grpc-tutorial/pingtest"
+	pb "github.com/jergoo/go-grpc-tutorial/protos/ping" // 引入编译生成的包
+)
+
+func newTestServer(t *testing.T, impl pb.PingPongServer) (*pingtest.Server, *ServerMetrics, *ClientMetrics) {
+	server, client := NewServerMetrics(), NewClientMetrics()
+	srv := pingtest.NewServer(t, impl,
+		pingtest.WithServerOptions(
+			grpc.UnaryInterceptor(server.UnaryServerInterceptor()),
+			grpc.StreamInterceptor(server.StreamServerInterceptor()),
+		),
+		pingtest.WithDialOptions(
+			grpc.WithUnaryInterceptor(client.UnaryClientInterceptor()),
+			grpc.WithStreamInterceptor(client.StreamClientInterceptor()),
+		),
+	)
+	return srv, server, client
+}
+
+func TestMetrics(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name       string
+		typ        string
+		method     string
+		call       func(client pb.PingPongClient) error
+		serverRecv float64
+		serverSent float64
+	}{
+		{
+			name: "unary", typ: Unary, method: "Ping",
+			call: func(client pb.PingPongClient) error {
+				_, err := client.Ping(ctx, &pb.PingRequest{Value: "ping"})
+				return err
+			},
+		},
+		{
+			name: "server stream", typ: ServerStream, method: "MultiPong",
+			call: func(client pb.PingPongClient) error {
+				stream, err := client.MultiPong(ctx, &pb.PingRequest{Value: "ping"})
+				if err != nil {
+					return err
+				}
+				for {
+					if _, err := stream.Recv(); err == io.EOF {
+						return nil
+					} else if err != nil {
+						return err
+					}
+				}
+			},
+			serverRecv: 1, serverSent: 3,
+		},
+		{
+			name: "client stream", typ: ClientStream, method: "MultiPing",
+			call: func(client pb.PingPongClient) error {
+				stream, err := client.MultiPing(ctx)
+				if err != nil {
+					return err
+				}
+				for i := 0; i < 2; i++ {
+					if err := stream.Send(&pb.PingRequest{Value: "ping"}); err != nil {
+						return err
+					}
+				}
+				_, err = stream.CloseAndRecv()
+				return err
+			},
+			serverRecv: 2, serverSent: 1,
+		},
+		{
+			name: "bidi stream", typ: BidiStream, method: "MultiPingPong",
+			call: func(client pb.PingPongClient) error {
+				stream, err := client.MultiPingPong(ctx)
+				if err != nil {
+					return err
+				}
+				for i := 0; i < 4; i++ {
+					if err := stream.Send(&pb.PingRequest{Value: "ping"}); err != nil {
+						return err
+					}
+				}
+				stream.CloseSend()
+				for {
+					if _, err := stream.Recv(); err == io.EOF {
+						return nil
+					} else if err != nil {
+						return err
+					}
+				}
+			},
+			serverRecv: 4, serverSent: 2,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			srv, server, client := newTestServer(t, pingpong.NewPingPongServer(pingpong.WithPongCount(3)))
+			if err := tt.call(srv.Client); err != nil {
+				t.Fatal(err)
+			}
+			labels := []string{tt.typ, "protos.PingPong", tt.method}
+			codeLabels := append(labels, "OK")
+			for side, m := range map[string]*metrics{"server": server.metrics, "client": client.metrics} {
+				if got := testutil.ToFloat64(m.started.WithLabelValues(labels...)); got != 1 {
+					t.Errorf("%s started = %v", side, got)
+				}
+				if got := testutil.ToFloat64(m.handled.WithLabelValues(codeLabels...)); got != 1 {
+					t.Errorf("%s handled = %v", side, got)
+				}
+				if got := testutil.ToFloat64(m.inFlight.WithLabelValues(labels...)); got != 0 {
+					t.Errorf("%s in flight = %v", side, got)
+				}
+			}
+			if got := testutil.ToFloat64(server.msgRecv.WithLabelValues(labels...)); got != tt.serverRecv {
+				t.Errorf("server received = %v, want %v", got, tt.serverRecv)
+			}
+			if got := testutil.ToFloat64(server.msgSent.WithLabelValues(labels...)); got != tt.serverSent {
+				t.Errorf("server sent = %v, want %v", got, tt.serverSent)
+			}
+			// 客户端和服务端收发的消息数相反
+			if got := testutil.ToFloat64(client.msgSent.WithLabelValues(labels...)); got != tt.serverRecv {
+				t.Errorf("client sent = %v, want %v", got, tt.serverRecv)
+			}
+			if got := testutil.ToFloat64(client.msgRecv.WithLabelValues(labels...)); got != tt.serverSent {
+				t.Errorf("client received = %v, want %v", got, tt.serverSent)
+			}
+			if got := testutil.CollectAndCount(server.latency); got != 1 {
+				t.Errorf("latency series = %d", got)
+			}
+		})
+	}
+}
+
+func TestHandledCode(t *testing.T) {
+	srv, server, client := newTestServer(t, &pb.UnimplementedPingPongServer{})
+	srv.Client.Ping(context.Background(), &pb.PingRequest{Value: "ping"})
+
+	labels := []string{Unary, "protos.PingPong", "Ping", "Unimplemented"}
+	if got := testutil.ToFloat64(server.handled.WithLabelValues(labels...)); got != 1 {
+		t.Errorf("server handled = %v", got)
+	}
+	if got := testutil.ToFloat64(client.handled.WithLabelValues(labels...)); got != 1 {
+		t.Errorf("client handled = %v", got)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	server := NewServerMetrics(WithNamespace("tutorial"))
+	reg.MustRegister(server)
+	srv := pingpong.NewServer(pingpong.WithGRPCOptions(
+		grpc.UnaryInterceptor(server.UnaryServerInterceptor()),
+		grpc.StreamInterceptor(server.StreamServerInterceptor()),
+	))
+	server.InitializeMetrics(srv.Server)
+
+	rec := httptest.NewRecorder()
+	NewMux(reg).ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body := rec.Body.String()
+	for _, want := range []string{
+		`tutorial_grpc_server_started_total{grpc_method="MultiPingPong",grpc_service="protos.PingPong",grpc_type="bidi_stream"} 0`,
+		`tutorial_grpc_server_started_total{grpc_method="Check",grpc_service="grpc.health.v1.Health",grpc_type="unary"} 0`,
+		`tutorial_grpc_server_msg_sent_total{grpc_method="MultiPong",grpc_service="protos.PingPong",grpc_type="server_stream"} 0`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics missing %s", want)
+		}
+	}
+}
@@ -0,0 +1,221 @@
+// Package middleware 按名称注册拦截器，并按指定顺序和方法组合为拦截器链
+//
+// 同一个 Middleware 可以同时包含服务端和客户端拦截器，通过同一份配置生成
+// grpc.ServerOption 和 grpc.DialOption，保证服务端和客户端使用相同的顺序和方法范围。
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"sync"
+
+	"google.golang.org/grpc"
+)
+
+// Middleware 一组同名拦截器，未设置的拦截器在对应的链中跳过
+type Middleware struct {
+	UnaryServer  grpc.UnaryServerInterceptor
+	StreamServer grpc.StreamServerInterceptor
+	UnaryClient  grpc.UnaryClientInterceptor
+	StreamClient grpc.StreamClientInterceptor
+}
+
+// Registry 拦截器注册表
+type Registry struct {
+	mu          sync.RWMutex
+	middlewares map[string]Middleware
+}
+
+// NewRegistry 创建空的注册表
+func NewRegistry() *Registry {
+	return &Registry{middlewares: make(map[string]Middleware)}
+}
+
+// Register 注册名称为 name 的拦截器，名称重复时返回错误
+func (r *Registry) Register(name string, m Middleware) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.middlewares[name]; ok {
+		return fmt.Errorf("middleware %q already registered", name)
+	}
+	r.middlewares[name] = m
+	return nil
+}
+
+// MustRegister 同 Register，名称重复时 panic
+func (r *Registry) MustRegister(name string, m Middleware) *Registry {
+	if err := r.Register(name, m); err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// Names 已注册的拦截器名称
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.middlewares))
+	for name := range r.middlewares {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Step 拦截器链中的一项
+type Step struct {
+	name    string
+	methods []string
+	except  []string
+}
+
+// Use 使用名称为 name 的拦截器，methods 指定生效的完整方法名，支持 path.Match 通配，如 /protos.PingPong/*，
+// 未指定时对全部方法生效
+func Use(name string, methods ...string) Step {
+	return Step{name: name, methods: methods}
+}
+
+// Except 排除 methods 指定的方法，优先于 Use 指定的方法
+func (s Step) Except(methods ...string) Step {
+	s.except = append(append([]string(nil), s.except...), methods...)
+	return s
+}
+
+// match 判断拦截器是否对 method 生效
+func (s Step) match(method string) bool {
+	for _, pattern := range s.except {
+		if ok, _ := path.Match(pattern, method); ok {
+			return false
+		}
+	}
+	if len(s.methods) == 0 {
+		return true
+	}
+	for _, pattern := range s.methods {
+		if ok, _ := path.Match(pattern, method); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// link 拦截器链中已解析的一项
+type link struct {
+	Step
+	Middleware
+}
+
+// Chain 有序的拦截器链，第一项在最外层，最先执行
+type Chain struct {
+	links []link
+}
+
+// Build 按 steps 的顺序组合拦截器链，拦截器未注册或方法通配格式错误时返回错误
+func (r *Registry) Build(steps ...Step) (*Chain, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	c := &Chain{}
+	for _, s := range steps {
+		m, ok := r.middlewares[s.name]
+		if !ok {
+			return nil, fmt.Errorf("middleware %q not registered", s.name)
+		}
+		for _, pattern := range append(append([]string(nil), s.methods...), s.except...) {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("middleware %q: invalid method pattern %q", s.name, pattern)
+			}
+		}
+		c.links = append(c.links, link{Step: s, Middleware: m})
+	}
+	return c, nil
+}
+
+// ServerOptions 服务端拦截器链配置
+func (c *Chain) ServerOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(c.UnaryServerInterceptors()...),
+		grpc.ChainStreamInterceptor(c.StreamServerInterceptors()...),
+	}
+}
+
+// DialOptions 客户端拦截器链配置
+func (c *Chain) DialOptions() []grpc.DialOption {
+	return []grpc.DialOption{
+		grpc.WithChainUnaryInterceptor(c.UnaryClientInterceptors()...),
+		grpc.WithChainStreamInterceptor(c.StreamClientInterceptors()...),
+	}
+}
+
+// UnaryServerInterceptors 按顺序返回服务端拦截器，只对匹配的方法执行
+func (c *Chain) UnaryServerInterceptors() []grpc.UnaryServerInterceptor {
+	var out []grpc.UnaryServerInterceptor
+	for _, l := range c.links {
+		if l.UnaryServer == nil {
+			continue
+		}
+		l := l
+		out = append(out, func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			if !l.match(info.FullMethod) {
+				return handler(ctx, req)
+			}
+			return l.UnaryServer(ctx, req, info, handler)
+		})
+	}
+	return out
+}
+
+// StreamServerInterceptors 按顺序返回服务端流拦截器，只对匹配的方法执行
+func (c *Chain) StreamServerInterceptors() []grpc.StreamServerInterceptor {
+	var out []grpc.StreamServerInterceptor
+	for _, l := range c.links {
+		if l.StreamServer == nil {
+			continue
+		}
+		l := l
+		out = append(out, func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			if !l.match(info.FullMethod) {
+				return handler(srv, ss)
+			}
+			return l.StreamServer(srv, ss, info, handler)
+		})
+	}
+	return out
+}
+
+// UnaryClientInterceptors 按顺序返回客户端拦截器，只对匹配的方法执行
+func (c *Chain) UnaryClientInterceptors() []grpc.UnaryClientInterceptor {
+	var out []grpc.UnaryClientInterceptor
+	for _, l := range c.links {
+		if l.UnaryClient == nil {
+			continue
+		}
+		l := l
+		out = append(out, func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+			if !l.match(method) {
+				return invoker(ctx, method, req, reply, cc, opts...)
+			}
+			return l.UnaryClient(ctx, method, req, reply, cc, invoker, opts...)
+		})
+	}
+	return out
+}
+
+// StreamClientInterceptors 按顺序返回客户端流拦截器，只对匹配的方法执行
+func (c *Chain) StreamClientInterceptors() []grpc.StreamClientInterceptor {
+	var out []grpc.StreamClientInterceptor
+	for _, l := range c.links {
+		if l.StreamClient == nil {
+			continue
+		}
+		l := l
+		out = append(out, func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+			if !l.match(method) {
+				return streamer(ctx, desc, cc, method, opts...)
+			}
+			return l.StreamClient(ctx, desc, cc, method, streamer, opts...)
+		})
+	}
+	return out
+}
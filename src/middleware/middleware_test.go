@@ -0,0 +1,172 @@
+package middleware
+
+import (
+	"context"
+	"io"
+	"reflect"
+	"sync"
+	"testing"
+
+	"google.golang.org/grpc"
+
+	"github.com/jergoo/go-grpc-tutorial/pingpong"
+	"github.com/jergoo/go-grpc-tutorial/pingtest"
+	pb "github.com/jergoo/go-grpc-tutorial/protos/ping" // 引入编译生成的包
+)
+
+// recorder 记录拦截器执行顺序
+type recorder struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (r *recorder) add(call string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, call)
+}
+
+func (r *recorder) take() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	calls := r.calls
+	r.calls = nil
+	return calls
+}
+
+// middleware 记录调用的拦截器
+func (r *recorder) middleware(name string) Middleware {
+	return Middleware{
+		UnaryServer: func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			r.add("server:" + name)
+			return handler(ctx, req)
+		},
+		StreamServer: func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			r.add("server:" + name)
+			return handler(srv, ss)
+		},
+		UnaryClient: func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+			r.add("client:" + name)
+			return invoker(ctx, method, req, reply, cc, opts...)
+		},
+		StreamClient: func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+			r.add("client:" + name)
+			return streamer(ctx, desc, cc, method, opts...)
+		},
+	}
+}
+
+func TestChain(t *testing.T) {
+	rec := &recorder{}
+	reg := NewRegistry().
+		MustRegister("logging", rec.middleware("logging")).
+		MustRegister("auth", rec.middleware("auth")).
+		MustRegister("metrics", rec.middleware("metrics")).
+		// 只有服务端拦截器
+		MustRegister("recovery", Middleware{
+			UnaryServer: func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+				rec.add("server:recovery")
+				return handler(ctx, req)
+			},
+		})
+	chain, err := reg.Build(
+		Use("recovery"),
+		Use("logging"),
+		Use("auth", "/protos.PingPong/*").Except("/protos.PingPong/MultiPong"),
+		Use("metrics", "/protos.PingPong/Multi*"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := pingtest.NewServer(t, pingpong.NewPingPongServer(),
+		pingtest.WithServerOptions(chain.ServerOptions()...),
+		pingtest.WithDialOptions(chain.DialOptions()...),
+	)
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		call func() error
+		want []string
+	}{
+		{
+			name: "unary",
+			call: func() error {
+				_, err := srv.Client.Ping(ctx, &pb.PingRequest{Value: "ping"})
+				return err
+			},
+			want: []string{"client:logging", "client:auth", "server:recovery", "server:logging", "server:auth"},
+		},
+		{
+			name: "except",
+			call: func() error {
+				stream, err := srv.Client.MultiPong(ctx, &pb.PingRequest{Value: "ping"})
+				if err != nil {
+					return err
+				}
+				for {
+					if _, err := stream.Recv(); err != nil {
+						if err == io.EOF {
+							return nil
+						}
+						return err
+					}
+				}
+			},
+			want: []string{"client:logging", "client:metrics", "server:logging", "server:metrics"},
+		},
+		{
+			name: "stream",
+			call: func() error {
+				stream, err := srv.Client.MultiPing(ctx)
+				if err != nil {
+					return err
+				}
+				if err := stream.Send(&pb.PingRequest{Value: "ping"}); err != nil {
+					return err
+				}
+				_, err = stream.CloseAndRecv()
+				return err
+			},
+			want: []string{"client:logging", "client:auth", "client:metrics", "server:logging", "server:auth", "server:metrics"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec.take()
+			if err := tt.call(); err != nil {
+				t.Fatal(err)
+			}
+			if got := rec.take(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("calls = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuild(t *testing.T) {
+	reg := NewRegistry().MustRegister("logging", Middleware{})
+	if err := reg.Register("logging", Middleware{}); err == nil {
+		t.Error("duplicate name registered")
+	}
+
+	tests := []struct {
+		name    string
+		steps   []Step
+		wantErr bool
+	}{
+		{name: "empty"},
+		{name: "registered", steps: []Step{Use("logging", "/protos.PingPong/*")}},
+		{name: "not registered", steps: []Step{Use("auth")}, wantErr: true},
+		{name: "bad pattern", steps: []Step{Use("logging", "/protos.PingPong/[")}, wantErr: true},
+		{name: "bad except pattern", steps: []Step{Use("logging").Except("[")}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := reg.Build(tt.steps...)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("err = %v, wantErr %t", err, tt.wantErr)
+			}
+		})
+	}
+}
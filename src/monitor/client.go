@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log"
+	"log/slog"
+	"os"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/jergoo/go-grpc-tutorial/logging"
+	pb "github.com/jergoo/go-grpc-tutorial/protos/ping" // 引入编译生成的包
+	"github.com/jergoo/go-grpc-tutorial/tracing"
+)
+
+// dialOptions 客户端监控拦截器，opts 追加在拦截器之后
+//
+// span 使用 otel.SetTracerProvider 设置的全局 TracerProvider，未设置时不导出。
+func dialOptions(opts ...grpc.DialOption) []grpc.DialOption {
+	logOpts := []logging.Option{logging.WithLogger(slog.New(slog.NewJSONHandler(os.Stdout, nil)))}
+	return append([]grpc.DialOption{
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithChainUnaryInterceptor(tracing.UnaryClientInterceptor(), logging.UnaryClientInterceptor(logOpts...)),
+		grpc.WithChainStreamInterceptor(tracing.StreamClientInterceptor(), logging.StreamClientInterceptor(logOpts...)),
+	}, opts...)
+}
+
+// Ping 单次请求-响应模式
+func Ping(target string, opts ...grpc.DialOption) error {
+	conn, err := grpc.Dial(target, dialOptions(opts...)...)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// 实例化客户端并调用
+	client := pb.NewPingPongClient(conn)
+	res, err := client.Ping(context.Background(), &pb.PingRequest{Value: "ping"})
+	if err != nil {
+		return err
+	}
+	log.Println(res.Value)
+	return nil
+}
+
+// MultiPong 服务端流模式
+func MultiPong(target string, opts ...grpc.DialOption) error {
+	conn, err := grpc.Dial(target, dialOptions(opts...)...)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// 实例化客户端并调用
+	client := pb.NewPingPongClient(conn)
+	stream, err := client.MultiPong(context.Background(), &pb.PingRequest{Value: "ping"})
+	if err != nil {
+		return err
+	}
+
+	// 循环接收数据流，读取到流结束时输出调用日志
+	for {
+		msg, err := stream.Recv()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return err
+		}
+		log.Println(msg.Value)
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/jergoo/go-grpc-tutorial/metrics"
+	"github.com/jergoo/go-grpc-tutorial/pingpong"
+	"github.com/jergoo/go-grpc-tutorial/pingtest"
+	pb "github.com/jergoo/go-grpc-tutorial/protos/ping" // 引入编译生成的包
+	"github.com/jergoo/go-grpc-tutorial/tracing"
+)
+
+// syncBuffer 并发安全的日志输出
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestMonitor(t *testing.T) {
+	tests := []struct {
+		name string
+		call func(target string, opts ...grpc.DialOption) error
+		typ  string
+	}{
+		{name: "Ping", call: Ping, typ: metrics.Unary},
+		{name: "MultiPong", call: MultiPong, typ: metrics.ServerStream},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			buf := &syncBuffer{}
+			serverMetrics := metrics.NewServerMetrics()
+			tp, exporter := tracing.NewInMemory()
+			srv := pingtest.NewServer(t, pingpong.NewPingPongServer(),
+				pingtest.WithServerOptions(serverOptions(slog.New(slog.NewJSONHandler(buf, nil)), serverMetrics, tp)...))
+			if err := tt.call(pingtest.Target, srv.DialOption()); err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.String(); !strings.Contains(got, `"grpc.method":"/protos.PingPong/`+tt.name+`"`) || !strings.Contains(got, `"grpc.code":"OK"`) {
+				t.Errorf("log = %s", got)
+			}
+			// 导出的指标包含调用的方法
+			rec := httptest.NewRecorder()
+			reg := prometheus.NewRegistry()
+			reg.MustRegister(serverMetrics)
+			metrics.NewMux(reg).ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+			want := fmt.Sprintf(`grpc_server_handled_total{grpc_code="OK",grpc_method=%q,grpc_service="protos.PingPong",grpc_type=%q} 1`, tt.name, tt.typ)
+			if !strings.Contains(rec.Body.String(), want) {
+				t.Errorf("metrics missing %s", want)
+			}
+			// 服务端 span
+			if spans := exporter.GetSpans(); len(spans) != 1 || spans[0].Name != "protos.PingPong/"+tt.name {
+				t.Errorf("spans = %v", spans)
+			}
+		})
+	}
+}
+
+// panicTracerProvider 创建 span 时 panic，模拟外层拦截器中的 panic
+type panicTracerProvider struct{ noop.TracerProvider }
+
+func (panicTracerProvider) Tracer(string, ...trace.TracerOption) trace.Tracer { return panicTracer{} }
+
+type panicTracer struct{ noop.Tracer }
+
+func (panicTracer) Start(context.Context, string, ...trace.SpanStartOption) (context.Context, trace.Span) {
+	panic("tracer broken")
+}
+
+func TestInterceptorPanic(t *testing.T) {
+	tests := []struct {
+		name string
+		call func(target string, opts ...grpc.DialOption) error
+	}{
+		{name: "Ping", call: Ping},
+		{name: "MultiPong", call: MultiPong},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			buf := &syncBuffer{}
+			srv := pingtest.NewServer(t, pingpong.NewPingPongServer(),
+				pingtest.WithServerOptions(serverOptions(slog.New(slog.NewJSONHandler(buf, nil)), metrics.NewServerMetrics(), panicTracerProvider{})...))
+			// 追踪拦截器中的 panic 由最外层的 recovery 捕获
+			if err := tt.call(pingtest.Target, srv.DialOption()); status.Code(err) != codes.Internal {
+				t.Fatalf("err = %v, want %s", err, codes.Internal)
+			}
+			if got := buf.String(); !strings.Contains(got, "tracer broken") {
+				t.Errorf("log = %s", got)
+			}
+		})
+	}
+}
+
+// panicPingPong 处理方法 panic 的服务
+type panicPingPong struct {
+	pb.UnimplementedPingPongServer
+}
+
+func (panicPingPong) Ping(context.Context, *pb.PingRequest) (*pb.PongResponse, error) {
+	panic("handler broken")
+}
+
+func (panicPingPong) MultiPong(*pb.PingRequest, pb.PingPong_MultiPongServer) error {
+	panic("handler broken")
+}
+
+func TestHandlerPanic(t *testing.T) {
+	tests := []struct {
+		name string
+		call func(target string, opts ...grpc.DialOption) error
+		typ  string
+	}{
+		{name: "Ping", call: Ping, typ: metrics.Unary},
+		{name: "MultiPong", call: MultiPong, typ: metrics.ServerStream},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			buf := &syncBuffer{}
+			serverMetrics := metrics.NewServerMetrics()
+			tp, exporter := tracing.NewInMemory()
+			srv := pingtest.NewServer(t, panicPingPong{},
+				pingtest.WithServerOptions(serverOptions(slog.New(slog.NewJSONHandler(buf, nil)), serverMetrics, tp)...))
+			if err := tt.call(pingtest.Target, srv.DialOption()); status.Code(err) != codes.Internal {
+				t.Fatalf("err = %v, want %s", err, codes.Internal)
+			}
+
+			// panic 时内层的指标、追踪、日志拦截器同样结束记录
+			rec := httptest.NewRecorder()
+			reg := prometheus.NewRegistry()
+			reg.MustRegister(serverMetrics)
+			metrics.NewMux(reg).ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+			labels := fmt.Sprintf(`grpc_method=%q,grpc_service="protos.PingPong",grpc_type=%q`, tt.name, tt.typ)
+			for _, want := range []string{
+				`grpc_server_handled_total{grpc_code="Internal",` + labels + `} 1`,
+				`grpc_server_in_flight{` + labels + `} 0`,
+			} {
+				if !strings.Contains(rec.Body.String(), want) {
+					t.Errorf("metrics missing %s", want)
+				}
+			}
+			if spans := exporter.GetSpans(); len(spans) != 1 || spans[0].Status.Code != otelcodes.Error {
+				t.Errorf("spans = %v", spans)
+			}
+			if got := buf.String(); !strings.Contains(got, `"msg":"finished call"`) || !strings.Contains(got, `"grpc.code":"Internal"`) || !strings.Contains(got, "handler broken") {
+				t.Errorf("log = %s", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+
+	"github.com/jergoo/go-grpc-tutorial/logging"
+	"github.com/jergoo/go-grpc-tutorial/metrics"
+	"github.com/jergoo/go-grpc-tutorial/pingpong"
+	"github.com/jergoo/go-grpc-tutorial/recovery"
+	"github.com/jergoo/go-grpc-tutorial/tracing"
+)
+
+// serverOptions 服务端监控拦截器，recovery 在最外层捕获包括其他拦截器在内的 panic，之后依次创建 span、记录指标、输出日志
+func serverOptions(logger *slog.Logger, serverMetrics *metrics.ServerMetrics, tp trace.TracerProvider) []grpc.ServerOption {
+	logOpts := []logging.Option{logging.WithLogger(logger)}
+	recoveryOpts := []recovery.Option{recovery.WithLogger(logger), recovery.WithMetrics(serverMetrics)}
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(
+			recovery.UnaryServerInterceptor(recoveryOpts...),
+			tracing.UnaryServerInterceptor(tracing.WithTracerProvider(tp)),
+			serverMetrics.UnaryServerInterceptor(),
+			logging.UnaryServerInterceptor(logOpts...),
+		),
+		grpc.ChainStreamInterceptor(
+			recovery.StreamServerInterceptor(recoveryOpts...),
+			tracing.StreamServerInterceptor(tracing.WithTracerProvider(tp)),
+			serverMetrics.StreamServerInterceptor(),
+			logging.StreamServerInterceptor(logOpts...),
+		),
+	}
+}
+
+// newTracerProvider 创建 TracerProvider，exporter 为 stdout 或 otlp
+func newTracerProvider(ctx context.Context, exporter, endpoint string) (*sdktrace.TracerProvider, error) {
+	exp, err := tracing.NewExporter(ctx, exporter, os.Stderr, endpoint)
+	if err != nil {
+		return nil, err
+	}
+	return tracing.NewTracerProvider("pingpong-server", exp)
+}
+
+// 启动server
+func main() {
+	exporter := flag.String("exporter", tracing.ExporterStdout, "trace exporter: stdout or otlp")
+	endpoint := flag.String("otlp-endpoint", tracing.DefaultOTLPEndpoint, "otlp collector endpoint")
+	flag.Parse()
+
+	// JSON 格式日志输出到标准输出
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+
+	// span 导出到标准错误或 OpenTelemetry Collector，退出前导出剩余的 span
+	tp, err := newTracerProvider(context.Background(), *exporter, *endpoint)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer tp.Shutdown(context.Background())
+	otel.SetTracerProvider(tp)
+
+	// 注册 gRPC 服务端指标和 Go 运行时、进程指标
+	reg := prometheus.NewRegistry()
+	serverMetrics := metrics.NewServerMetrics()
+	reg.MustRegister(serverMetrics, collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+
+	srv := pingpong.NewServer(pingpong.WithGRPCOptions(serverOptions(logger, serverMetrics, tp)...))
+	// 初始化全部方法的指标
+	serverMetrics.InitializeMetrics(srv.Server)
+
+	// 在 9090 端口通过 /metrics 导出指标
+	httpSrv := &http.Server{Addr: ":9090", Handler: metrics.NewMux(reg)}
+	go func() {
+		if err := httpSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatal(err)
+		}
+	}()
+	defer httpSrv.Close()
+
+	lis, err := net.Listen("tcp", ":1234")
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Println("listen on 1234, metrics on 9090")
+	// 收到 SIGINT/SIGTERM 后优雅退出
+	report, err := srv.Run(context.Background(), lis)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("server stopped: %s", report)
+}
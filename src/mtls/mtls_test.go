@@ -0,0 +1,212 @@
+package mtls
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/jergoo/go-grpc-tutorial/pingpong"
+	"github.com/jergoo/go-grpc-tutorial/pingtest"
+	pb "github.com/jergoo/go-grpc-tutorial/protos/ping" // 引入编译生成的包
+)
+
+// testCA 测试使用的 CA，签发的证书写入临时目录
+type testCA struct {
+	dir  string
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	ca := &testCA{dir: t.TempDir(), cert: cert, key: key}
+	ca.write(t, "ca.crt", "CERTIFICATE", der)
+	return ca
+}
+
+func (ca *testCA) write(t *testing.T, name, typ string, der []byte) string {
+	t.Helper()
+	path := filepath.Join(ca.dir, name)
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// issue 签发证书，返回证书和私钥文件路径
+func (ca *testCA) issue(t *testing.T, name string, tmpl *x509.Certificate) (certFile, keyFile string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl.SerialNumber = big.NewInt(time.Now().UnixNano())
+	tmpl.NotBefore = time.Now().Add(-time.Hour)
+	tmpl.NotAfter = time.Now().Add(time.Hour)
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ca.write(t, name+".crt", "CERTIFICATE", der), ca.write(t, name+".key", "PRIVATE KEY", keyDER)
+}
+
+func TestAuthorize(t *testing.T) {
+	spiffe, _ := url.Parse("spiffe://example.org/a")
+	id := &Identity{CommonName: "a", DNSNames: []string{"a.example.org"}, URIs: []*url.URL{spiffe}}
+
+	tests := []struct {
+		name   string
+		policy *Policy
+		method string
+		code   codes.Code
+	}{
+		{name: "empty policy", policy: NewPolicy(), method: "/protos.PingPong/Ping", code: codes.PermissionDenied},
+		{name: "common name", policy: NewPolicy().Allow("a", "/protos.PingPong/Ping"), method: "/protos.PingPong/Ping"},
+		{name: "dns san", policy: NewPolicy().Allow("a.example.org", "/protos.PingPong/*"), method: "/protos.PingPong/MultiPong"},
+		{name: "spiffe id", policy: NewPolicy().Allow("spiffe://example.org/a", "/protos.PingPong/*"), method: "/protos.PingPong/Ping"},
+		{name: "any identity", policy: NewPolicy().Allow(AnyIdentity, "/grpc.health.v1.Health/*"), method: "/grpc.health.v1.Health/Check"},
+		{name: "other identity", policy: NewPolicy().Allow("b", "/protos.PingPong/*"), method: "/protos.PingPong/Ping", code: codes.PermissionDenied},
+		{name: "other method", policy: NewPolicy().Allow("a", "/protos.PingPong/Ping"), method: "/protos.PingPong/MultiPong", code: codes.PermissionDenied},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.Authorize(id, tt.method)
+			if status.Code(err) != tt.code {
+				t.Errorf("code = %v, want %v", status.Code(err), tt.code)
+			}
+		})
+	}
+}
+
+func TestInterceptor(t *testing.T) {
+	ca := newTestCA(t)
+	caFile := filepath.Join(ca.dir, "ca.crt")
+	serverCert, serverKey := ca.issue(t, "server", &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "grpc.server"},
+		DNSNames:    []string{"grpc.server"},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	})
+	spiffe, _ := url.Parse("spiffe://example.org/a")
+	aCert, aKey := ca.issue(t, "a", &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "a"},
+		URIs:        []*url.URL{spiffe},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	bCert, bKey := ca.issue(t, "b", &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "b"},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+
+	serverConfig, err := ServerTLSConfig(serverCert, serverKey, caFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	policy := NewPolicy().Allow("spiffe://example.org/a", "/protos.PingPong/Ping", "/protos.PingPong/MultiPingPong")
+	impl := pingpong.NewPingPongServer(pingpong.WithHeaderFunc(func(ctx context.Context, method string) metadata.MD {
+		if id, ok := FromContext(ctx); ok {
+			return metadata.Pairs("peer", id.String())
+		}
+		return nil
+	}))
+
+	tests := []struct {
+		name     string
+		cert     string
+		key      string
+		pingCode codes.Code
+		pongCode codes.Code
+		bidiCode codes.Code
+	}{
+		{name: "allowed", cert: aCert, key: aKey, pongCode: codes.PermissionDenied},
+		{name: "denied", cert: bCert, key: bKey, pingCode: codes.PermissionDenied, pongCode: codes.PermissionDenied, bidiCode: codes.PermissionDenied},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			clientConfig, err := ClientTLSConfig(tt.cert, tt.key, caFile, "grpc.server")
+			if err != nil {
+				t.Fatal(err)
+			}
+			srv := pingtest.NewServer(t, impl,
+				pingtest.WithTLS(credentials.NewTLS(serverConfig), credentials.NewTLS(clientConfig)),
+				pingtest.WithServerOptions(
+					grpc.UnaryInterceptor(policy.UnaryServerInterceptor()),
+					grpc.StreamInterceptor(policy.StreamServerInterceptor()),
+				),
+			)
+			ctx := context.Background()
+
+			var header metadata.MD
+			_, err = srv.Client.Ping(ctx, &pb.PingRequest{Value: "ping"}, grpc.Header(&header))
+			if status.Code(err) != tt.pingCode {
+				t.Errorf("Ping code = %v, want %v", status.Code(err), tt.pingCode)
+			}
+			if err == nil {
+				if got := header.Get("peer"); len(got) != 1 || got[0] != "spiffe://example.org/a" {
+					t.Errorf("peer = %v", got)
+				}
+			}
+
+			pong, err := srv.Client.MultiPong(ctx, &pb.PingRequest{Value: "ping"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := pong.Recv(); status.Code(err) != tt.pongCode {
+				t.Errorf("MultiPong code = %v, want %v", status.Code(err), tt.pongCode)
+			}
+
+			bidi, err := srv.Client.MultiPingPong(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			bidi.CloseSend()
+			_, err = bidi.Recv()
+			if err == io.EOF {
+				err = nil
+			}
+			if status.Code(err) != tt.bidiCode {
+				t.Errorf("MultiPingPong code = %v, want %v", status.Code(err), tt.bidiCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package mtls
+
+import (
+	"context"
+	"path"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// AnyIdentity 匹配任意已验证的客户端证书
+const AnyIdentity = "*"
+
+// rule 单条授权规则
+type rule struct {
+	identity string
+	methods  []string
+}
+
+// Policy 身份到可调用方法的映射，未匹配任何规则的请求被拒绝
+type Policy struct {
+	rules []rule
+}
+
+// NewPolicy 创建空的授权策略，默认拒绝所有请求
+func NewPolicy() *Policy {
+	return &Policy{}
+}
+
+// Allow 允许 identity 调用 methods
+//
+// identity 与证书的 CN、DNS SAN 或 URI SAN（如 spiffe://example.org/client）之一相同即匹配，AnyIdentity 匹配任意身份。
+// methods 为完整方法名，支持 path.Match 通配，如 /protos.PingPong/*。
+func (p *Policy) Allow(identity string, methods ...string) *Policy {
+	p.rules = append(p.rules, rule{identity: identity, methods: methods})
+	return p
+}
+
+// Authorize 检查 id 是否允许调用 fullMethod，拒绝时返回 codes.PermissionDenied 错误
+func (p *Policy) Authorize(id *Identity, fullMethod string) error {
+	names := id.Names()
+	for _, r := range p.rules {
+		if !matchIdentity(r.identity, names) {
+			continue
+		}
+		for _, pattern := range r.methods {
+			if ok, _ := path.Match(pattern, fullMethod); ok {
+				return nil
+			}
+		}
+	}
+	return status.Errorf(codes.PermissionDenied, "%s is not allowed to call %s", id, fullMethod)
+}
+
+func matchIdentity(identity string, names []string) bool {
+	if identity == AnyIdentity {
+		return true
+	}
+	for _, name := range names {
+		if name == identity {
+			return true
+		}
+	}
+	return false
+}
+
+// authorize 读取客户端证书身份并检查授权，返回保存了身份的 context
+func (p *Policy) authorize(ctx context.Context, fullMethod string) (context.Context, error) {
+	id, err := PeerIdentity(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.Authorize(id, fullMethod); err != nil {
+		return nil, err
+	}
+	return NewContext(ctx, id), nil
+}
+
+// UnaryServerInterceptor 服务端拦截器 - 证书身份授权
+func (p *Policy) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := p.authorize(ctx, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor 服务端流拦截器 - 证书身份授权
+func (p *Policy) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := p.authorize(ss.Context(), info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &identityServerStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// identityServerStream 包装 grpc.ServerStream，替换 Context
+type identityServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *identityServerStream) Context() context.Context {
+	return s.ctx
+}
@@ -1,57 +1,37 @@
 package main
 
 import (
+	"context"
 	"testing"
-)
-
-func TestPing(t *testing.T) {
-	tests := []struct {
-		name string
-	}{
-		{name: "ping"},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			Ping()
-		})
-	}
-}
 
-func TestMultiPong(t *testing.T) {
-	tests := []struct {
-		name string
-	}{
-		{name: "multi pong"},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			MultiPong()
-		})
-	}
-}
-
-func TestMultiPing(t *testing.T) {
-	tests := []struct {
-		name string
-	}{
-		{name: "multi ping"},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			MultiPing()
-		})
-	}
-}
+	"github.com/jergoo/go-grpc-tutorial/pingclient"
+	"github.com/jergoo/go-grpc-tutorial/pingpong"
+	"github.com/jergoo/go-grpc-tutorial/pingtest"
+)
 
-func TestMultiPingPong(t *testing.T) {
+func TestClient(t *testing.T) {
 	tests := []struct {
 		name string
+		call func(ctx context.Context, c *pingclient.Client) error
 	}{
-		{name: "multi ping&pong"},
+		{name: "ping", call: Ping},
+		{name: "multi pong", call: MultiPong},
+		{name: "multi ping", call: MultiPing},
+		{name: "multi ping&pong", call: MultiPingPong},
 	}
 	for _, tt := range tests {
+		tt := tt
 		t.Run(tt.name, func(t *testing.T) {
-			MultiPingPong()
+			t.Parallel()
+			srv := pingtest.NewServer(t, pingpong.NewPingPongServer())
+			c, err := pingclient.New(pingtest.Target, pingclient.WithDialOptions(srv.DialOption()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer c.Close()
+			if err := tt.call(context.Background(), c); err != nil {
+				t.Fatal(err)
+			}
 		})
 	}
 }
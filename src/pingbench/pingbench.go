@@ -0,0 +1,302 @@
+// Package pingbench PingPong 服务的吞吐测试
+//
+// 建立多个连接，每个连接上并发多个流，在指定时间内循环调用 MultiPong（服务端到客户端）
+// 或 MultiPing（客户端到服务端），统计消息速率、数据速率和调用延迟分位数。
+package pingbench
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/jergoo/go-grpc-tutorial/pingclient"
+	pb "github.com/jergoo/go-grpc-tutorial/protos/ping" // 引入编译生成的包
+)
+
+// 测试方向
+const (
+	ModeDownload = "download" // MultiPong，服务端发送
+	ModeUpload   = "upload"   // MultiPing，客户端发送
+)
+
+// 默认配置
+const (
+	DefaultConns       = 1
+	DefaultStreams     = 1
+	DefaultDuration    = 10 * time.Second
+	DefaultCount       = 100
+	DefaultPayloadSize = 1024
+)
+
+// options 测试配置
+type options struct {
+	mode        string
+	conns       int
+	streams     int
+	duration    time.Duration
+	count       int
+	payloadSize int
+	interval    time.Duration
+	clientOpts  []pingclient.Option
+}
+
+// Option 测试配置项
+type Option func(*options)
+
+// WithMode 测试方向，ModeDownload 或 ModeUpload，默认 ModeDownload
+func WithMode(mode string) Option {
+	return func(o *options) {
+		o.mode = mode
+	}
+}
+
+// WithConcurrency 连接数和每个连接上的并发流数
+func WithConcurrency(conns, streams int) Option {
+	return func(o *options) {
+		o.conns, o.streams = conns, streams
+	}
+}
+
+// WithDuration 测试时长，到达时长后不再发起新的调用，进行中的调用正常结束
+func WithDuration(d time.Duration) Option {
+	return func(o *options) {
+		o.duration = d
+	}
+}
+
+// WithMessages 每次调用的消息数、每条消息的负载字节数和消息发送间隔
+func WithMessages(count, payloadSize int, interval time.Duration) Option {
+	return func(o *options) {
+		o.count, o.payloadSize, o.interval = count, payloadSize, interval
+	}
+}
+
+// WithClientOptions 客户端配置，如 TLS 证书
+func WithClientOptions(opts ...pingclient.Option) Option {
+	return func(o *options) {
+		o.clientOpts = append(o.clientOpts, opts...)
+	}
+}
+
+// Percentiles 延迟分位数
+type Percentiles struct {
+	P50 time.Duration
+	P90 time.Duration
+	P99 time.Duration
+	Max time.Duration
+}
+
+// percentiles 按 nearest-rank 方法计算分位数，会对 latencies 排序
+func percentiles(latencies []time.Duration) Percentiles {
+	if len(latencies) == 0 {
+		return Percentiles{}
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	rank := func(p int) time.Duration {
+		i := (len(latencies)*p+99)/100 - 1
+		if i < 0 {
+			i = 0
+		}
+		return latencies[i]
+	}
+	return Percentiles{P50: rank(50), P90: rank(90), P99: rank(99), Max: latencies[len(latencies)-1]}
+}
+
+// Result 测试结果
+type Result struct {
+	Mode     string
+	Conns    int
+	Streams  int
+	Elapsed  time.Duration // 实际运行时间
+	Calls    int           // 成功的调用数
+	Errors   int           // 失败的调用数
+	Messages int64         // 成功传输的消息数
+	Bytes    int64         // 成功传输的负载字节数
+	Latency  Percentiles   // 每次调用从发起到结束的时间
+	LastErr  error         // 最后一次调用错误
+}
+
+// MessagesPerSec 每秒消息数
+func (r *Result) MessagesPerSec() float64 {
+	if r.Elapsed <= 0 {
+		return 0
+	}
+	return float64(r.Messages) / r.Elapsed.Seconds()
+}
+
+// MBPerSec 每秒负载数据量，1MB = 10^6 字节
+func (r *Result) MBPerSec() float64 {
+	if r.Elapsed <= 0 {
+		return 0
+	}
+	return float64(r.Bytes) / 1e6 / r.Elapsed.Seconds()
+}
+
+// String 文本格式的测试报告
+func (r *Result) String() string {
+	out := fmt.Sprintf("%s: %d conns x %d streams, %s\n", r.Mode, r.Conns, r.Streams, r.Elapsed.Round(time.Millisecond))
+	out += fmt.Sprintf("calls: %d ok, %d errors\n", r.Calls, r.Errors)
+	out += fmt.Sprintf("throughput: %.1f msgs/sec, %.2f MB/sec\n", r.MessagesPerSec(), r.MBPerSec())
+	out += fmt.Sprintf("call latency: p50 %s, p90 %s, p99 %s, max %s", r.Latency.P50, r.Latency.P90, r.Latency.P99, r.Latency.Max)
+	if r.LastErr != nil {
+		out += fmt.Sprintf("\nlast error: %v", r.LastErr)
+	}
+	return out
+}
+
+// worker 单个流的统计，结束后合并
+type worker struct {
+	calls, errors   int
+	messages, bytes int64
+	latencies       []time.Duration
+	lastErr         error
+}
+
+// Run 对 target 运行吞吐测试，ctx 取消时中断进行中的调用并返回已完成部分的结果
+func Run(ctx context.Context, target string, opts ...Option) (*Result, error) {
+	o := &options{
+		mode:        ModeDownload,
+		conns:       DefaultConns,
+		streams:     DefaultStreams,
+		duration:    DefaultDuration,
+		count:       DefaultCount,
+		payloadSize: DefaultPayloadSize,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.mode != ModeDownload && o.mode != ModeUpload {
+		return nil, fmt.Errorf("unknown mode %q", o.mode)
+	}
+	if o.conns < 1 || o.streams < 1 || o.count < 1 || o.payloadSize < 0 {
+		return nil, fmt.Errorf("invalid concurrency %dx%d or messages %d x %d bytes", o.conns, o.streams, o.count, o.payloadSize)
+	}
+
+	clients := make([]*pingclient.Client, o.conns)
+	for i := range clients {
+		c, err := pingclient.New(target, o.clientOpts...)
+		if err != nil {
+			return nil, err
+		}
+		defer c.Close()
+		clients[i] = c
+	}
+
+	workers := make([]*worker, o.conns*o.streams)
+	start := time.Now()
+	deadline := start.Add(o.duration)
+	var wg sync.WaitGroup
+	for i := range workers {
+		w := &worker{}
+		workers[i] = w
+		c := clients[i%o.conns]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for time.Now().Before(deadline) && ctx.Err() == nil {
+				w.call(ctx, c, o)
+			}
+		}()
+	}
+	wg.Wait()
+
+	r := &Result{Mode: o.mode, Conns: o.conns, Streams: o.streams, Elapsed: time.Since(start)}
+	var latencies []time.Duration
+	for _, w := range workers {
+		r.Calls += w.calls
+		r.Errors += w.errors
+		r.Messages += w.messages
+		r.Bytes += w.bytes
+		if w.lastErr != nil {
+			r.LastErr = w.lastErr
+		}
+		latencies = append(latencies, w.latencies...)
+	}
+	r.Latency = percentiles(latencies)
+	return r, ctx.Err()
+}
+
+// call 执行一次调用并记录结果，ctx 取消导致的失败不计入统计
+func (w *worker) call(ctx context.Context, c *pingclient.Client, o *options) {
+	begin := time.Now()
+	var (
+		messages, bytes int64
+		err             error
+	)
+	switch o.mode {
+	case ModeDownload:
+		messages, bytes, err = download(ctx, c, o)
+	case ModeUpload:
+		messages, bytes, err = upload(ctx, c, o)
+	}
+	if err != nil {
+		if ctx.Err() == nil {
+			w.errors++
+			w.lastErr = err
+		}
+		return
+	}
+	w.calls++
+	w.messages += messages
+	w.bytes += bytes
+	w.latencies = append(w.latencies, time.Since(begin))
+}
+
+// download 调用 MultiPong，由服务端按请求参数发送消息
+func download(ctx context.Context, c *pingclient.Client, o *options) (messages, bytes int64, err error) {
+	req := &pb.PingRequest{
+		Value: "bench",
+		Options: &pb.StreamOptions{
+			ReplyCount:    uint32(o.count),
+			PayloadSize:   uint32(o.payloadSize),
+			ReplyInterval: durationpb.New(o.interval),
+		},
+	}
+	err = c.MultiPongFunc(ctx, req, func(res *pb.PongResponse) error {
+		messages++
+		bytes += int64(len(res.Payload))
+		return nil
+	})
+	return messages, bytes, err
+}
+
+// upload 调用 MultiPing，以服务端确认收到的消息数和字节数为准
+func upload(ctx context.Context, c *pingclient.Client, o *options) (messages, bytes int64, err error) {
+	payload := make([]byte, o.payloadSize)
+	req := &pb.PingRequest{Value: "bench", Payload: payload}
+	// 第一条消息指定最多接收的消息数，放开服务端的默认限制
+	first := &pb.PingRequest{Value: "bench", Payload: payload, Options: &pb.StreamOptions{MaxMessages: uint32(o.count)}}
+	reqs := make(chan *pb.PingRequest)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		defer close(reqs)
+		for i := 0; i < o.count; i++ {
+			if i > 0 && o.interval > 0 {
+				select {
+				case <-time.After(o.interval):
+				case <-ctx.Done():
+					return
+				}
+			}
+			msg := req
+			if i == 0 {
+				msg = first
+			}
+			select {
+			case reqs <- msg:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	res, err := c.MultiPingFrom(ctx, reqs)
+	if err != nil {
+		return 0, 0, err
+	}
+	return int64(res.Received), int64(res.ReceivedBytes), nil
+}
@@ -0,0 +1,126 @@
+package pingbench
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/jergoo/go-grpc-tutorial/pingclient"
+	"github.com/jergoo/go-grpc-tutorial/pingpong"
+	"github.com/jergoo/go-grpc-tutorial/pingtest"
+)
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    []Option
+		size    int64
+		count   int64
+		wantErr codes.Code
+	}{
+		{
+			name: "download",
+			opts: []Option{WithMode(ModeDownload), WithMessages(10, 256, 0)},
+			size: 256, count: 10,
+		},
+		{
+			name: "upload",
+			opts: []Option{WithMode(ModeUpload), WithMessages(10, 128, 0)},
+			size: 128, count: 10,
+		},
+		{
+			name:    "payload too large",
+			opts:    []Option{WithMode(ModeDownload), WithMessages(1, int(pingpong.MaxPayloadSize)+1, 0)},
+			wantErr: codes.InvalidArgument,
+		},
+		{
+			name:  "interval",
+			opts:  []Option{WithMode(ModeDownload), WithMessages(3, 0, time.Millisecond)},
+			count: 3,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			srv := pingtest.NewServer(t, pingpong.NewPingPongServer())
+			opts := append([]Option{
+				WithConcurrency(2, 2),
+				WithDuration(50 * time.Millisecond),
+				WithClientOptions(pingclient.WithDialOptions(srv.DialOption())),
+			}, tt.opts...)
+			r, err := Run(context.Background(), pingtest.Target, opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantErr != codes.OK {
+				if r.Calls != 0 || r.Errors == 0 || status.Code(r.LastErr) != tt.wantErr {
+					t.Errorf("calls = %d, errors = %d, last error = %v", r.Calls, r.Errors, r.LastErr)
+				}
+				return
+			}
+			if r.Calls == 0 || r.Errors != 0 {
+				t.Fatalf("calls = %d, errors = %d, last error = %v", r.Calls, r.Errors, r.LastErr)
+			}
+			if r.Messages != int64(r.Calls)*tt.count || r.Bytes != r.Messages*tt.size {
+				t.Errorf("calls = %d, messages = %d, bytes = %d", r.Calls, r.Messages, r.Bytes)
+			}
+			if r.Elapsed < 50*time.Millisecond || r.MessagesPerSec() <= 0 || r.Latency.P50 <= 0 || r.Latency.Max < r.Latency.P99 {
+				t.Errorf("result = %+v", r)
+			}
+		})
+	}
+}
+
+func TestRunCancel(t *testing.T) {
+	srv := pingtest.NewServer(t, pingpong.NewPingPongServer())
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	// 间隔较长，调用被取消，不计入统计
+	r, err := Run(ctx, pingtest.Target,
+		WithDuration(time.Hour),
+		WithMessages(2, 0, 30*time.Second),
+		WithClientOptions(pingclient.WithDialOptions(srv.DialOption())),
+	)
+	if err != context.DeadlineExceeded || r.Calls != 0 || r.Errors != 0 {
+		t.Errorf("err = %v, result = %+v", err, r)
+	}
+}
+
+func TestPercentiles(t *testing.T) {
+	latencies := make([]time.Duration, 100)
+	for i := range latencies {
+		latencies[i] = time.Duration(100-i) * time.Millisecond
+	}
+	tests := []struct {
+		name      string
+		latencies []time.Duration
+		want      Percentiles
+	}{
+		{name: "empty"},
+		{name: "one", latencies: []time.Duration{time.Second}, want: Percentiles{time.Second, time.Second, time.Second, time.Second}},
+		{name: "1..100ms", latencies: latencies, want: Percentiles{50 * time.Millisecond, 90 * time.Millisecond, 99 * time.Millisecond, 100 * time.Millisecond}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := percentiles(tt.latencies); got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInvalidOptions(t *testing.T) {
+	for _, opts := range [][]Option{
+		{WithMode("sideways")},
+		{WithConcurrency(0, 1)},
+		{WithMessages(0, 10, 0)},
+	} {
+		if _, err := Run(context.Background(), pingtest.Target, opts...); err == nil {
+			t.Errorf("want error for %d options", len(opts))
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package pingclient
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "github.com/jergoo/go-grpc-tutorial/protos/ping" // 引入编译生成的包
+)
+
+// Reply 一次延迟探测的结果
+type Reply struct {
+	Seq    uint64        // 请求序号，从 1 开始
+	RTT    time.Duration // 往返时间
+	Offset time.Duration // 服务端时钟相对本地时钟的估计偏移，服务端未返回时间时为 0
+	Err    error         // 调用失败或响应序号不匹配，记为丢失
+}
+
+// newReply 根据客户端发送时间 t0、接收时间 t3 和服务端收发时间 t1、t2 计算结果
+//
+// 时钟偏移按 NTP 的方式估计：((t1 - t0) + (t2 - t3)) / 2，假设往返链路延迟对称。
+func newReply(seq uint64, t0, t3 time.Time, res *pb.PongResponse, err error) Reply {
+	r := Reply{Seq: seq, RTT: t3.Sub(t0), Err: err}
+	if err != nil {
+		return r
+	}
+	if res.Seq != seq {
+		r.Err = fmt.Errorf("unexpected seq %d, want %d", res.Seq, seq)
+		return r
+	}
+	if res.ReceivedAt != nil && res.SentAt != nil {
+		// 去掉单调时钟读数，与服务端的墙上时间比较
+		t0, t3 = t0.Round(0), t3.Round(0)
+		t1, t2 := res.ReceivedAt.AsTime(), res.SentAt.AsTime()
+		r.Offset = (t1.Sub(t0) + t2.Sub(t3)) / 2
+	}
+	return r
+}
+
+// Stats 延迟统计，零值可用，通过 Add 累计每次探测结果
+type Stats struct {
+	Sent     int           // 发送的请求数
+	Received int           // 成功收到的响应数
+	Min      time.Duration // 最小往返时间
+	Avg      time.Duration // 平均往返时间
+	Max      time.Duration // 最大往返时间
+	StdDev   time.Duration // 往返时间标准差，即 ping(8) 的 mdev
+	Jitter   time.Duration // 相邻两次往返时间差的平均值
+	Offset   time.Duration // 往返时间最小的一次探测估计的时钟偏移，链路排队最少，估计最准确
+
+	sum, sumSq float64 // 往返时间的和与平方和，单位纳秒
+	diffSum    time.Duration
+	last       time.Duration
+}
+
+// Add 累计一次探测结果
+func (s *Stats) Add(r Reply) {
+	s.Sent++
+	if r.Err != nil {
+		return
+	}
+	s.Received++
+	if s.Received == 1 || r.RTT < s.Min {
+		s.Min = r.RTT
+		s.Offset = r.Offset
+	}
+	if r.RTT > s.Max {
+		s.Max = r.RTT
+	}
+	if s.Received > 1 {
+		d := r.RTT - s.last
+		if d < 0 {
+			d = -d
+		}
+		s.diffSum += d
+		s.Jitter = s.diffSum / time.Duration(s.Received-1)
+	}
+	s.last = r.RTT
+
+	n := float64(s.Received)
+	s.sum += float64(r.RTT)
+	s.sumSq += float64(r.RTT) * float64(r.RTT)
+	avg := s.sum / n
+	s.Avg = time.Duration(avg)
+	s.StdDev = time.Duration(math.Sqrt(math.Max(s.sumSq/n-avg*avg, 0)))
+}
+
+// Loss 丢失比例，0 到 1
+func (s *Stats) Loss() float64 {
+	if s.Sent == 0 {
+		return 0
+	}
+	return float64(s.Sent-s.Received) / float64(s.Sent)
+}
+
+// String 按 ping(8) 的格式输出统计结果
+func (s *Stats) String() string {
+	out := fmt.Sprintf("%d requests transmitted, %d received, %.1f%% loss", s.Sent, s.Received, s.Loss()*100)
+	if s.Received > 0 {
+		out += fmt.Sprintf("\nrtt min/avg/max/mdev = %s/%s/%s/%s, jitter %s, clock offset %s",
+			s.Min, s.Avg, s.Max, s.StdDev, s.Jitter, s.Offset)
+	}
+	return out
+}
+
+// Latency 按 interval 间隔发送 count 次 Ping 并统计延迟，count <= 0 时持续发送直到 ctx 取消
+//
+// 每次探测结束调用 fn，调用失败记为丢失并继续探测。ctx 取消时返回已完成部分的统计和 ctx 的错误，
+// 被取消的那次调用不计入统计。
+func (c *Client) Latency(ctx context.Context, count int, interval time.Duration, fn func(Reply)) (*Stats, error) {
+	stats := &Stats{}
+	for seq := uint64(1); count <= 0 || seq <= uint64(count); seq++ {
+		if seq > 1 && !wait(ctx, interval) {
+			return stats, ctx.Err()
+		}
+		t0 := time.Now()
+		res, err := c.Ping(ctx, &pb.PingRequest{Value: "ping", Seq: seq, SentAt: timestamppb.New(t0)})
+		t3 := time.Now()
+		if err != nil && ctx.Err() != nil {
+			return stats, ctx.Err()
+		}
+		r := newReply(seq, t0, t3, res, err)
+		stats.Add(r)
+		if fn != nil {
+			fn(r)
+		}
+	}
+	return stats, nil
+}
+
+// wait 等待 d，ctx 取消时返回 false
+func wait(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
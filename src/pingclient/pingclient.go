@@ -0,0 +1,261 @@
+// Package pingclient 可复用的 PingPong 客户端
+//
+// Client 创建时建立一个 grpc.ClientConn，四种调用模式共用该连接，调用通过 context 取消，
+// 错误全部返回给调用方，不会退出进程。
+package pingclient
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+
+	pb "github.com/jergoo/go-grpc-tutorial/protos/ping" // 引入编译生成的包
+)
+
+// options 客户端配置
+type options struct {
+	creds       credentials.TransportCredentials
+	dialOpts    []grpc.DialOption
+	unary       []grpc.UnaryClientInterceptor
+	stream      []grpc.StreamClientInterceptor
+	callTimeout time.Duration
+}
+
+// Option 客户端配置项
+type Option func(*options)
+
+// WithTransportCredentials 传输层认证，默认不加密
+func WithTransportCredentials(creds credentials.TransportCredentials) Option {
+	return func(o *options) {
+		o.creds = creds
+	}
+}
+
+// WithPerRPCCredentials 每次调用携带的认证信息，如 token
+func WithPerRPCCredentials(creds credentials.PerRPCCredentials) Option {
+	return WithDialOptions(grpc.WithPerRPCCredentials(creds))
+}
+
+// WithUnaryInterceptors 添加单次调用拦截器，按参数顺序执行
+func WithUnaryInterceptors(interceptors ...grpc.UnaryClientInterceptor) Option {
+	return func(o *options) {
+		o.unary = append(o.unary, interceptors...)
+	}
+}
+
+// WithStreamInterceptors 添加流调用拦截器，按参数顺序执行
+func WithStreamInterceptors(interceptors ...grpc.StreamClientInterceptor) Option {
+	return func(o *options) {
+		o.stream = append(o.stream, interceptors...)
+	}
+}
+
+// WithDialOptions 添加其他连接配置
+func WithDialOptions(opts ...grpc.DialOption) Option {
+	return func(o *options) {
+		o.dialOpts = append(o.dialOpts, opts...)
+	}
+}
+
+// WithCallTimeout 每次调用的超时时间，context 已设置 deadline 时不生效，流调用包含整个流的时间
+func WithCallTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.callTimeout = d
+	}
+}
+
+// Client PingPong 客户端，并发安全
+type Client struct {
+	conn        *grpc.ClientConn
+	client      pb.PingPongClient
+	callTimeout time.Duration
+}
+
+// New 创建客户端，target 格式参考 grpc.NewClient，如 localhost:1234
+//
+// 连接在第一次调用时建立，不再使用时调用 Close 关闭连接。
+func New(target string, opts ...Option) (*Client, error) {
+	o := &options{creds: insecure.NewCredentials()}
+	for _, opt := range opts {
+		opt(o)
+	}
+	dialOpts := append([]grpc.DialOption{
+		grpc.WithTransportCredentials(o.creds),
+		grpc.WithChainUnaryInterceptor(o.unary...),
+		grpc.WithChainStreamInterceptor(o.stream...),
+	}, o.dialOpts...)
+	conn, err := grpc.NewClient(target, dialOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{conn: conn, client: pb.NewPingPongClient(conn), callTimeout: o.callTimeout}, nil
+}
+
+// Conn 返回客户端使用的连接
+func (c *Client) Conn() *grpc.ClientConn {
+	return c.conn
+}
+
+// Close 关闭连接
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// withTimeout 为没有 deadline 的 context 设置调用超时
+func (c *Client) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok || c.callTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, c.callTimeout)
+}
+
+// Ping 单次请求-响应模式
+func (c *Client) Ping(ctx context.Context, req *pb.PingRequest, opts ...grpc.CallOption) (*pb.PongResponse, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	return c.client.Ping(ctx, req, opts...)
+}
+
+// MultiPong 服务端流模式，返回全部响应
+func (c *Client) MultiPong(ctx context.Context, req *pb.PingRequest, opts ...grpc.CallOption) ([]*pb.PongResponse, error) {
+	var out []*pb.PongResponse
+	err := c.MultiPongFunc(ctx, req, func(res *pb.PongResponse) error {
+		out = append(out, res)
+		return nil
+	}, opts...)
+	return out, err
+}
+
+// MultiPongFunc 服务端流模式，每收到一条响应调用 fn，fn 返回错误时取消调用并返回该错误
+func (c *Client) MultiPongFunc(ctx context.Context, req *pb.PingRequest, fn func(*pb.PongResponse) error, opts ...grpc.CallOption) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	stream, err := c.client.MultiPong(ctx, req, opts...)
+	if err != nil {
+		return err
+	}
+	for {
+		res, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := fn(res); err != nil {
+			return err
+		}
+	}
+}
+
+// MultiPing 客户端流模式，依次发送 reqs 并返回服务端响应
+func (c *Client) MultiPing(ctx context.Context, reqs []*pb.PingRequest, opts ...grpc.CallOption) (*pb.PongResponse, error) {
+	return c.MultiPingFrom(ctx, feed(reqs), opts...)
+}
+
+// MultiPingFrom 客户端流模式，发送 reqs 中的消息直到 reqs 关闭，返回服务端响应
+func (c *Client) MultiPingFrom(ctx context.Context, reqs <-chan *pb.PingRequest, opts ...grpc.CallOption) (*pb.PongResponse, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	stream, err := c.client.MultiPing(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if err := send(ctx, stream, reqs); err != nil && err != io.EOF {
+		return nil, err
+	}
+	// 服务端提前结束时 Send 返回 io.EOF，结果通过 CloseAndRecv 获取
+	return stream.CloseAndRecv()
+}
+
+// MultiPingPong 双向流模式，发送 reqs 的同时接收响应，返回全部响应
+func (c *Client) MultiPingPong(ctx context.Context, reqs []*pb.PingRequest, opts ...grpc.CallOption) ([]*pb.PongResponse, error) {
+	var out []*pb.PongResponse
+	err := c.MultiPingPongFunc(ctx, feed(reqs), func(res *pb.PongResponse) error {
+		out = append(out, res)
+		return nil
+	}, opts...)
+	return out, err
+}
+
+// MultiPingPongFunc 双向流模式，在另一个 goroutine 中发送 reqs 的消息直到 reqs 关闭，
+// 每收到一条响应调用 fn，fn 返回错误时取消调用并返回该错误
+func (c *Client) MultiPingPongFunc(ctx context.Context, reqs <-chan *pb.PingRequest, fn func(*pb.PongResponse) error, opts ...grpc.CallOption) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	stream, err := c.client.MultiPingPong(ctx, opts...)
+	if err != nil {
+		return err
+	}
+
+	var (
+		wg      sync.WaitGroup
+		sendErr error
+	)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if err := send(ctx, stream, reqs); err != nil && err != io.EOF {
+			// 发送失败时取消调用，结束接收
+			sendErr = err
+			cancel()
+			return
+		}
+		stream.CloseSend()
+	}()
+
+	for {
+		res, err := stream.Recv()
+		if err == nil {
+			err = fn(res)
+			if err == nil {
+				continue
+			}
+		}
+		// 接收结束后取消调用并等待发送结束，避免 goroutine 泄漏
+		cancel()
+		wg.Wait()
+		if err == io.EOF {
+			return nil
+		}
+		if sendErr != nil {
+			return sendErr
+		}
+		return err
+	}
+}
+
+// send 发送 reqs 中的消息直到 reqs 关闭，context 取消时停止发送，调用结果由接收方返回
+func send(ctx context.Context, stream grpc.ClientStream, reqs <-chan *pb.PingRequest) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case req, ok := <-reqs:
+			if !ok {
+				return nil
+			}
+			if err := stream.SendMsg(req); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// feed 返回依次输出 reqs 的已关闭 channel
+func feed(reqs []*pb.PingRequest) <-chan *pb.PingRequest {
+	ch := make(chan *pb.PingRequest, len(reqs))
+	for _, req := range reqs {
+		ch <- req
+	}
+	close(ch)
+	return ch
+}
@@ -0,0 +1,512 @@
+package pingclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/jergoo/go-grpc-tutorial/pingpong"
+	"github.com/jergoo/go-grpc-tutorial/pingtest"
+	pb "github.com/jergoo/go-grpc-tutorial/protos/ping" // 引入编译生成的包
+)
+
+func newTestClient(t *testing.T, impl pb.PingPongServer, opts ...Option) *Client {
+	srv := pingtest.NewServer(t, impl)
+	c, err := New(pingtest.Target, append([]Option{WithDialOptions(srv.DialOption())}, opts...)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func pings(n int) []*pb.PingRequest {
+	reqs := make([]*pb.PingRequest, n)
+	for i := range reqs {
+		reqs[i] = &pb.PingRequest{Value: "ping"}
+	}
+	return reqs
+}
+
+// blockingServer 流调用阻塞到客户端取消
+type blockingServer struct {
+	pb.UnimplementedPingPongServer
+}
+
+func (blockingServer) Ping(ctx context.Context, _ *pb.PingRequest) (*pb.PongResponse, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (blockingServer) MultiPing(stream pb.PingPong_MultiPingServer) error {
+	<-stream.Context().Done()
+	return stream.Context().Err()
+}
+
+func (blockingServer) MultiPingPong(stream pb.PingPong_MultiPingPongServer) error {
+	<-stream.Context().Done()
+	return stream.Context().Err()
+}
+
+func TestClient(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name    string
+		call    func(c *Client) (int, error)
+		want    int
+		wantErr codes.Code
+	}{
+		{
+			name: "ping",
+			call: func(c *Client) (int, error) {
+				_, err := c.Ping(ctx, &pb.PingRequest{Value: "ping"})
+				return 1, err
+			},
+			want: 1,
+		},
+		{
+			name: "multi pong",
+			call: func(c *Client) (int, error) {
+				res, err := c.MultiPong(ctx, &pb.PingRequest{Value: "ping"})
+				return len(res), err
+			},
+			want: 3,
+		},
+		{
+			name: "multi ping",
+			call: func(c *Client) (int, error) {
+				res, err := c.MultiPing(ctx, pings(4))
+				if err != nil {
+					return 0, err
+				}
+				if res.Value != "got 4 ping" {
+					t.Errorf("value = %s", res.Value)
+				}
+				return 1, nil
+			},
+			want: 1,
+		},
+		{
+			name: "multi ping exceeded",
+			call: func(c *Client) (int, error) {
+				_, err := c.MultiPing(ctx, pings(20))
+				return 0, err
+			},
+			wantErr: codes.ResourceExhausted,
+		},
+		{
+			name: "multi ping&pong",
+			call: func(c *Client) (int, error) {
+				res, err := c.MultiPingPong(ctx, pings(6))
+				return len(res), err
+			},
+			want: 3,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			c := newTestClient(t, pingpong.NewPingPongServer(pingpong.WithPongCount(3)))
+			got, err := tt.call(c)
+			if status.Code(err) != tt.wantErr {
+				t.Fatalf("err = %v, want %s", err, tt.wantErr)
+			}
+			if err == nil && got != tt.want {
+				t.Errorf("got %d responses, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSharedConn(t *testing.T) {
+	c := newTestClient(t, pingpong.NewPingPongServer())
+	for i := 0; i < 3; i++ {
+		if _, err := c.Ping(context.Background(), &pb.PingRequest{Value: "ping"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := c.MultiPong(context.Background(), &pb.PingRequest{Value: "ping"}); err != nil {
+		t.Fatal(err)
+	}
+	if c.Conn() == nil {
+		t.Error("conn is nil")
+	}
+}
+
+func TestInterceptors(t *testing.T) {
+	var unary, stream int
+	c := newTestClient(t, pingpong.NewPingPongServer(),
+		WithUnaryInterceptors(func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+			unary++
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}),
+		WithStreamInterceptors(func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+			stream++
+			return streamer(ctx, desc, cc, method, opts...)
+		}),
+	)
+	c.Ping(context.Background(), &pb.PingRequest{Value: "ping"})
+	c.MultiPingPong(context.Background(), pings(2))
+	if unary != 1 || stream != 1 {
+		t.Errorf("unary = %d, stream = %d", unary, stream)
+	}
+}
+
+func TestCancel(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    []Option
+		ctx     func() (context.Context, context.CancelFunc)
+		wantErr codes.Code
+	}{
+		{
+			name:    "call timeout",
+			opts:    []Option{WithCallTimeout(50 * time.Millisecond)},
+			ctx:     func() (context.Context, context.CancelFunc) { return context.Background(), func() {} },
+			wantErr: codes.DeadlineExceeded,
+		},
+		{
+			name: "context deadline",
+			opts: []Option{WithCallTimeout(time.Hour)},
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.Background(), 50*time.Millisecond)
+			},
+			wantErr: codes.DeadlineExceeded,
+		},
+		{
+			name: "context canceled",
+			ctx: func() (context.Context, context.CancelFunc) {
+				ctx, cancel := context.WithCancel(context.Background())
+				time.AfterFunc(50*time.Millisecond, cancel)
+				return ctx, cancel
+			},
+			wantErr: codes.Canceled,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			c := newTestClient(t, blockingServer{}, tt.opts...)
+
+			ctx, cancel := tt.ctx()
+			defer cancel()
+			if _, err := c.Ping(ctx, &pb.PingRequest{Value: "ping"}); status.Code(err) != tt.wantErr {
+				t.Errorf("ping err = %v, want %s", err, tt.wantErr)
+			}
+
+			ctx, cancel = tt.ctx()
+			defer cancel()
+			if _, err := c.MultiPingFrom(ctx, make(chan *pb.PingRequest)); status.Code(err) != tt.wantErr {
+				t.Errorf("multi ping err = %v, want %s", err, tt.wantErr)
+			}
+
+			ctx, cancel = tt.ctx()
+			defer cancel()
+			// 发送方阻塞在未关闭的 channel 上，取消后应和接收方一起返回
+			reqs := make(chan *pb.PingRequest)
+			err := c.MultiPingPongFunc(ctx, reqs, func(*pb.PongResponse) error { return nil })
+			if status.Code(err) != tt.wantErr {
+				t.Errorf("multi ping&pong err = %v, want %s", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCallbackError(t *testing.T) {
+	c := newTestClient(t, pingpong.NewPingPongServer(pingpong.WithPongCount(5), pingpong.WithBatchSize(1)))
+	stop := errors.New("stop")
+
+	n := 0
+	err := c.MultiPongFunc(context.Background(), &pb.PingRequest{Value: "ping"}, func(*pb.PongResponse) error {
+		n++
+		return stop
+	})
+	if err != stop || n != 1 {
+		t.Errorf("multi pong err = %v, n = %d", err, n)
+	}
+
+	reqs := make(chan *pb.PingRequest, 1)
+	reqs <- &pb.PingRequest{Value: "ping"}
+	err = c.MultiPingPongFunc(context.Background(), reqs, func(*pb.PongResponse) error { return stop })
+	if err != stop {
+		t.Errorf("multi ping&pong err = %v", err)
+	}
+}
+
+func TestServerError(t *testing.T) {
+	c := newTestClient(t, &pb.UnimplementedPingPongServer{})
+	// 服务端直接返回错误，发送方仍在等待 reqs
+	err := c.MultiPingPongFunc(context.Background(), make(chan *pb.PingRequest), func(*pb.PongResponse) error { return nil })
+	if status.Code(err) != codes.Unimplemented {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestStats(t *testing.T) {
+	ms := time.Millisecond
+	tests := []struct {
+		name    string
+		replies []Reply
+		want    Stats
+		loss    float64
+	}{
+		{name: "empty"},
+		{
+			name: "all received",
+			replies: []Reply{
+				{RTT: 2 * ms, Offset: 5 * ms},
+				{RTT: 4 * ms, Offset: 9 * ms},
+				{RTT: 1 * ms, Offset: 3 * ms},
+				{RTT: 5 * ms, Offset: 1 * ms},
+			},
+			// avg 3ms，方差 (1+1+4+4)/4 = 2.5ms²，相邻差值 2,3,4
+			want: Stats{Sent: 4, Received: 4, Min: ms, Avg: 3 * ms, Max: 5 * ms, StdDev: 1581138, Jitter: 3 * ms, Offset: 3 * ms},
+		},
+		{
+			name: "lost",
+			replies: []Reply{
+				{RTT: 2 * ms},
+				{Err: errors.New("lost")},
+				{RTT: 2 * ms},
+				{Err: errors.New("lost")},
+			},
+			want: Stats{Sent: 4, Received: 2, Min: 2 * ms, Avg: 2 * ms, Max: 2 * ms},
+			loss: 0.5,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var s Stats
+			for _, r := range tt.replies {
+				s.Add(r)
+			}
+			got := Stats{Sent: s.Sent, Received: s.Received, Min: s.Min, Avg: s.Avg, Max: s.Max, StdDev: s.StdDev, Jitter: s.Jitter, Offset: s.Offset}
+			if got != tt.want {
+				t.Errorf("stats = %+v, want %+v", got, tt.want)
+			}
+			if s.Loss() != tt.loss {
+				t.Errorf("loss = %v, want %v", s.Loss(), tt.loss)
+			}
+		})
+	}
+}
+
+func TestNewReply(t *testing.T) {
+	t0 := time.Unix(100, 0)
+	// 服务端时钟快 1s，单程 10ms，处理 2ms
+	res := &pb.PongResponse{
+		Seq:        3,
+		ReceivedAt: timestamppb.New(t0.Add(time.Second + 10*time.Millisecond)),
+		SentAt:     timestamppb.New(t0.Add(time.Second + 12*time.Millisecond)),
+	}
+	r := newReply(3, t0, t0.Add(22*time.Millisecond), res, nil)
+	if r.Err != nil || r.RTT != 22*time.Millisecond || r.Offset != time.Second {
+		t.Errorf("reply = %+v", r)
+	}
+	if r := newReply(4, t0, t0, res, nil); r.Err == nil {
+		t.Error("want seq mismatch error")
+	}
+}
+
+func TestLatency(t *testing.T) {
+	c := newTestClient(t, pingpong.NewPingPongServer())
+	var seqs []uint64
+	stats, err := c.Latency(context.Background(), 3, time.Millisecond, func(r Reply) {
+		if r.Err != nil {
+			t.Error(r.Err)
+		}
+		seqs = append(seqs, r.Seq)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Sent != 3 || stats.Received != 3 || stats.Min <= 0 || stats.Max < stats.Min {
+		t.Errorf("stats = %+v", stats)
+	}
+	if len(seqs) != 3 || seqs[0] != 1 || seqs[2] != 3 {
+		t.Errorf("seqs = %v", seqs)
+	}
+
+	// count <= 0 时持续发送直到取消
+	ctx, cancel := context.WithCancel(context.Background())
+	stats, err = c.Latency(ctx, 0, time.Millisecond, func(r Reply) {
+		if r.Seq == 5 {
+			cancel()
+		}
+	})
+	if err != context.Canceled || stats.Sent != 5 {
+		t.Errorf("err = %v, sent = %d", err, stats.Sent)
+	}
+}
+
+// breakStream 前 times 次调用在发送 after 条消息后以 code 中断流
+func breakStream(after, times int, code codes.Code) grpc.StreamServerInterceptor {
+	var calls atomic.Int32
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if int(calls.Add(1)) > times {
+			return handler(srv, ss)
+		}
+		return handler(srv, &brokenStream{ServerStream: ss, left: after, err: status.Error(code, "stream broken")})
+	}
+}
+
+type brokenStream struct {
+	grpc.ServerStream
+	left int
+	err  error
+}
+
+func (s *brokenStream) SendMsg(m interface{}) error {
+	if s.left == 0 {
+		return s.err
+	}
+	s.left--
+	return s.ServerStream.SendMsg(m)
+}
+
+func TestResumableMultiPong(t *testing.T) {
+	stop := errors.New("stop")
+	tests := []struct {
+		name        string
+		noReplay    bool // 服务端不开启断点续传
+		breaker     grpc.StreamServerInterceptor
+		opts        []ResumeOption
+		stopAt      uint64 // fn 在收到该 seq 时返回 stop
+		want        int    // 收到的消息数，seq 从 1 开始连续
+		wantRetries int
+		wantErr     error
+	}{
+		{name: "resume after break", breaker: breakStream(3, 2, codes.Unavailable), want: 10, wantRetries: 2},
+		{
+			name:    "retries exhausted",
+			breaker: breakStream(0, 100, codes.Unavailable), opts: []ResumeOption{WithMaxRetries(2)},
+			wantRetries: 2, wantErr: status.Error(codes.Unavailable, "stream broken"),
+		},
+		{
+			name:    "progress resets retries",
+			breaker: breakStream(1, 5, codes.Unavailable), opts: []ResumeOption{WithMaxRetries(1)},
+			want: 10, wantRetries: 5,
+		},
+		{
+			name:    "code not retryable",
+			breaker: breakStream(3, 1, codes.Internal),
+			want:    3, wantErr: status.Error(codes.Internal, "stream broken"),
+		},
+		{
+			name:    "retry codes",
+			breaker: breakStream(3, 1, codes.Internal), opts: []ResumeOption{WithRetryCodes(codes.Internal)},
+			want: 10, wantRetries: 1,
+		},
+		{
+			name:     "no resume token",
+			noReplay: true,
+			breaker:  breakStream(2, 1, codes.Unavailable),
+			want:     2, wantErr: status.Error(codes.Unavailable, "stream broken"),
+		},
+		{
+			name:     "no resume token before first message",
+			noReplay: true,
+			breaker:  breakStream(0, 1, codes.Unavailable),
+			want:     10, wantRetries: 1,
+		},
+		{name: "callback error", breaker: breakStream(3, 1, codes.Unavailable), stopAt: 5, want: 4, wantRetries: 1, wantErr: stop},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var serverOpts []pingpong.Option
+			if !tt.noReplay {
+				serverOpts = append(serverOpts, pingpong.WithReplay(pingpong.DefaultReplaySize, pingpong.DefaultReplayTTL))
+			}
+			srv := pingtest.NewServer(t, pingpong.NewPingPongServer(serverOpts...), pingtest.WithServerOptions(grpc.StreamInterceptor(tt.breaker)))
+			c, err := New(pingtest.Target, WithDialOptions(srv.DialOption()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer c.Close()
+
+			var got []uint64
+			retries := 0
+			opts := append([]ResumeOption{
+				WithBackoff(time.Millisecond, 10*time.Millisecond),
+				WithOnRetry(func(int, time.Duration, error) { retries++ }),
+			}, tt.opts...)
+			err = c.ResumableMultiPong(context.Background(), &pb.PingRequest{Value: "ping"}, func(res *pb.PongResponse) error {
+				if res.Seq == tt.stopAt {
+					return stop
+				}
+				got = append(got, res.Seq)
+				return nil
+			}, opts...)
+			if (err == nil) != (tt.wantErr == nil) || (err != nil && err.Error() != tt.wantErr.Error()) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if len(got) != tt.want {
+				t.Errorf("got %v, want %d messages", got, tt.want)
+			}
+			for i, seq := range got {
+				if seq != uint64(i+1) {
+					t.Fatalf("got %v", got)
+				}
+			}
+			if retries != tt.wantRetries {
+				t.Errorf("retries = %d, want %d", retries, tt.wantRetries)
+			}
+		})
+	}
+}
+
+func TestResumeServerRestart(t *testing.T) {
+	// 保留的消息在 PingPongServer 中，重启 grpc Server 后仍然可以继续
+	impl := pingpong.NewPingPongServer(pingpong.WithReplay(pingpong.DefaultReplaySize, pingpong.DefaultReplayTTL))
+	serve := func(addr string) (*grpc.Server, string) {
+		lis, err := net.Listen("tcp", addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		srv := grpc.NewServer()
+		pb.RegisterPingPongServer(srv, impl)
+		go srv.Serve(lis)
+		return srv, lis.Addr().String()
+	}
+	srv, addr := serve("127.0.0.1:0")
+	defer func() { srv.Stop() }()
+
+	c, err := New(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	var got []uint64
+	retries := 0
+	req := &pb.PingRequest{Value: "ping", Options: &pb.StreamOptions{ReplyCount: 6, ReplyInterval: durationpb.New(10 * time.Millisecond)}}
+	err = c.ResumableMultiPong(context.Background(), req, func(res *pb.PongResponse) error {
+		got = append(got, res.Seq)
+		// 收到第二条消息后重启服务，断开所有连接
+		if res.Seq == 2 {
+			srv.Stop()
+			srv, _ = serve(addr)
+		}
+		return nil
+	}, WithBackoff(10*time.Millisecond, 100*time.Millisecond), WithOnRetry(func(int, time.Duration, error) { retries++ }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(got) != "[1 2 3 4 5 6]" || retries == 0 {
+		t.Errorf("got %v, retries = %d", got, retries)
+	}
+}
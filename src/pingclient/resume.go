@@ -0,0 +1,167 @@
+package pingclient
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	pb "github.com/jergoo/go-grpc-tutorial/protos/ping" // 引入编译生成的包
+)
+
+// 断点续传默认配置
+const (
+	DefaultMaxRetries = 5                      // 没有收到新消息时连续重试的最多次数
+	DefaultMinBackoff = 100 * time.Millisecond // 第一次重试前等待的时间
+	DefaultMaxBackoff = 5 * time.Second        // 重试等待时间的上限
+)
+
+// resumeOptions 断点续传配置
+type resumeOptions struct {
+	maxRetries int
+	minBackoff time.Duration
+	maxBackoff time.Duration
+	retryCodes []codes.Code
+	onRetry    func(attempt int, delay time.Duration, err error)
+	callOpts   []grpc.CallOption
+}
+
+// ResumeOption 断点续传配置项
+type ResumeOption func(*resumeOptions)
+
+// WithMaxRetries 没有收到新消息时连续重试的最多次数，收到新消息后重新计数
+func WithMaxRetries(n int) ResumeOption {
+	return func(o *resumeOptions) {
+		o.maxRetries = n
+	}
+}
+
+// WithBackoff 重试等待时间从 min 开始每次翻倍，最长 max，实际等待时间在 [d/2, d] 之间随机
+func WithBackoff(min, max time.Duration) ResumeOption {
+	return func(o *resumeOptions) {
+		o.minBackoff, o.maxBackoff = min, max
+	}
+}
+
+// WithRetryCodes 可重试的状态码，默认只重试 codes.Unavailable
+func WithRetryCodes(c ...codes.Code) ResumeOption {
+	return func(o *resumeOptions) {
+		o.retryCodes = c
+	}
+}
+
+// WithOnRetry 每次重试前调用 fn，attempt 从 1 开始，err 为中断的原因
+func WithOnRetry(fn func(attempt int, delay time.Duration, err error)) ResumeOption {
+	return func(o *resumeOptions) {
+		o.onRetry = fn
+	}
+}
+
+// WithCallOptions 每次调用使用的 grpc.CallOption
+func WithCallOptions(opts ...grpc.CallOption) ResumeOption {
+	return func(o *resumeOptions) {
+		o.callOpts = append(o.callOpts, opts...)
+	}
+}
+
+// callbackError 区分 fn 返回的错误和调用的错误，fn 的错误不重试
+type callbackError struct{ err error }
+
+func (e *callbackError) Error() string { return e.err.Error() }
+
+// ResumableMultiPong 服务端流模式，每收到一条响应调用 fn，流中断时按退避时间重新调用，
+// 携带最后收到的 resume_token 从中断的位置继续，fn 不会收到重复的消息
+//
+// 服务端没有返回 resume_token 时，只在还没有收到消息时重试。
+// 服务端无法继续（如重启后丢失了保留的消息）时返回 codes.FailedPrecondition，
+// 调用超时 WithCallTimeout 作用于每次调用。
+func (c *Client) ResumableMultiPong(ctx context.Context, req *pb.PingRequest, fn func(*pb.PongResponse) error, opts ...ResumeOption) error {
+	o := &resumeOptions{
+		maxRetries: DefaultMaxRetries,
+		minBackoff: DefaultMinBackoff,
+		maxBackoff: DefaultMaxBackoff,
+		retryCodes: []codes.Code{codes.Unavailable},
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	req = proto.Clone(req).(*pb.PingRequest)
+	var (
+		last     uint64 // 最后收到的 seq
+		received int
+		attempt  int
+	)
+	for {
+		progressed := false
+		err := c.MultiPongFunc(ctx, req, func(res *pb.PongResponse) error {
+			// 丢弃已经收到的消息
+			if req.ResumeToken != "" && res.Seq <= last {
+				return nil
+			}
+			if err := fn(res); err != nil {
+				return &callbackError{err}
+			}
+			last, received, progressed = res.Seq, received+1, true
+			req.ResumeToken = res.ResumeToken
+			return nil
+		}, o.callOpts...)
+		if err == nil {
+			return nil
+		}
+		var cbErr *callbackError
+		if errors.As(err, &cbErr) {
+			return cbErr.err
+		}
+		// 收到过消息但无法从中断的位置继续，重新调用会收到重复的消息
+		if !o.retryable(err) || ctx.Err() != nil || (received > 0 && req.ResumeToken == "") {
+			return err
+		}
+
+		if progressed {
+			attempt = 0
+		}
+		attempt++
+		if attempt > o.maxRetries {
+			return err
+		}
+		delay := o.backoff(attempt)
+		if o.onRetry != nil {
+			o.onRetry(attempt, delay, err)
+		}
+		if !wait(ctx, delay) {
+			return status.FromContextError(ctx.Err()).Err()
+		}
+	}
+}
+
+// retryable err 的状态码是否可以重试
+func (o *resumeOptions) retryable(err error) bool {
+	code := status.Code(err)
+	for _, c := range o.retryCodes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+// backoff 第 attempt 次重试前等待的时间
+func (o *resumeOptions) backoff(attempt int) time.Duration {
+	d := o.minBackoff
+	for i := 1; i < attempt && d < o.maxBackoff; i++ {
+		d *= 2
+	}
+	if d > o.maxBackoff {
+		d = o.maxBackoff
+	}
+	if d <= 0 {
+		return 0
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
@@ -0,0 +1,126 @@
+package pingpong
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	pb "github.com/jergoo/go-grpc-tutorial/protos/ping" // 引入编译生成的包
+)
+
+// ServiceName PingPong 服务的完整名称，用于健康检查
+var ServiceName = pb.PingPong_ServiceDesc.ServiceName
+
+// DefaultHealthCheckInterval 默认依赖检查间隔
+const DefaultHealthCheckInterval = 10 * time.Second
+
+// HealthCheck 依赖检查，返回错误时对应服务置为 NOT_SERVING
+type HealthCheck func(ctx context.Context) error
+
+// healthCheck 注册的依赖检查
+type healthCheck struct {
+	service  string
+	interval time.Duration
+	check    HealthCheck
+}
+
+// WithHealthServices 注册额外服务的健康状态，如 example.ExampleService，初始为 SERVING
+func WithHealthServices(services ...string) ServerOption {
+	return func(o *serverOptions) {
+		o.healthServices = append(o.healthServices, services...)
+	}
+}
+
+// WithHealthCheck 每隔 interval 执行一次依赖检查，检查失败时 service 置为 NOT_SERVING，恢复后重新置为 SERVING
+//
+// 同一服务可以注册多个检查，全部通过时才为 SERVING。interval <= 0 时使用 DefaultHealthCheckInterval。
+func WithHealthCheck(service string, interval time.Duration, check HealthCheck) ServerOption {
+	return func(o *serverOptions) {
+		if interval <= 0 {
+			interval = DefaultHealthCheckInterval
+		}
+		o.healthChecks = append(o.healthChecks, healthCheck{service: service, interval: interval, check: check})
+	}
+}
+
+// newHealthServer 创建健康检查服务，空服务名表示整个 Server 的状态
+func newHealthServer(services []string) *health.Server {
+	hs := health.NewServer()
+	for _, service := range append([]string{"", ServiceName}, services...) {
+		hs.SetServingStatus(service, healthpb.HealthCheckResponse_SERVING)
+	}
+	return hs
+}
+
+// healthChecker 执行依赖检查并更新服务状态
+type healthChecker struct {
+	hs     *health.Server
+	checks []healthCheck
+
+	mu      sync.Mutex
+	failing map[string]map[int]bool // service -> 失败的检查
+}
+
+func newHealthChecker(hs *health.Server, checks []healthCheck) *healthChecker {
+	return &healthChecker{
+		hs:      hs,
+		checks:  checks,
+		failing: make(map[string]map[int]bool),
+	}
+}
+
+// run 执行全部依赖检查直到 ctx 取消
+func (c *healthChecker) run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for i, hc := range c.checks {
+		wg.Add(1)
+		go func(i int, hc healthCheck) {
+			defer wg.Done()
+			ticker := time.NewTicker(hc.interval)
+			defer ticker.Stop()
+			for {
+				c.runOnce(ctx, i, hc)
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+				}
+			}
+		}(i, hc)
+	}
+	wg.Wait()
+}
+
+// runOnce 执行一次检查，检查超时时间与检查间隔相同
+func (c *healthChecker) runOnce(ctx context.Context, i int, hc healthCheck) {
+	checkCtx, cancel := context.WithTimeout(ctx, hc.interval)
+	err := hc.check(checkCtx)
+	cancel()
+	// 服务退出中，忽略检查结果
+	if ctx.Err() != nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.failing[hc.service] == nil {
+		c.failing[hc.service] = make(map[int]bool)
+	}
+	if err != nil {
+		log.Printf("health check %s failed: %v", hc.service, err)
+		c.failing[hc.service][i] = true
+	} else {
+		delete(c.failing[hc.service], i)
+	}
+
+	status := healthpb.HealthCheckResponse_SERVING
+	if len(c.failing[hc.service]) > 0 {
+		status = healthpb.HealthCheckResponse_NOT_SERVING
+	}
+	// Shutdown 之后状态保持 NOT_SERVING，SetServingStatus 不再生效
+	c.hs.SetServingStatus(hc.service, status)
+}
@@ -0,0 +1,190 @@
+package pingpong
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/jergoo/go-grpc-tutorial/lifecycle"
+	"github.com/jergoo/go-grpc-tutorial/pingtest"
+)
+
+func TestHealthCheck(t *testing.T) {
+	srv := NewServer(WithHealthServices("example.ExampleService"))
+	client := healthpb.NewHealthClient(pingtest.Start(t, srv.Server).Conn)
+
+	tests := []struct {
+		name    string
+		service string
+		want    healthpb.HealthCheckResponse_ServingStatus
+		code    codes.Code
+	}{
+		{name: "server", service: "", want: healthpb.HealthCheckResponse_SERVING},
+		{name: "pingpong", service: ServiceName, want: healthpb.HealthCheckResponse_SERVING},
+		{name: "example", service: "example.ExampleService", want: healthpb.HealthCheckResponse_SERVING},
+		{name: "unknown", service: "unknown.Service", code: codes.NotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: tt.service})
+			if status.Code(err) != tt.code {
+				t.Fatalf("code = %v, want %v", status.Code(err), tt.code)
+			}
+			if err == nil && res.Status != tt.want {
+				t.Errorf("status = %v, want %v", res.Status, tt.want)
+			}
+		})
+	}
+}
+
+func TestHealthWatch(t *testing.T) {
+	srv := NewServer()
+	client := healthpb.NewHealthClient(pingtest.Start(t, srv.Server).Conn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	stream, err := client.Watch(ctx, &healthpb.HealthCheckRequest{Service: ServiceName})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []healthpb.HealthCheckResponse_ServingStatus{
+		healthpb.HealthCheckResponse_SERVING,
+		healthpb.HealthCheckResponse_NOT_SERVING,
+		healthpb.HealthCheckResponse_SERVING,
+	}
+	for i, w := range want {
+		res, err := stream.Recv()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Status != w {
+			t.Fatalf("#%d status = %v, want %v", i, res.Status, w)
+		}
+		if i+1 < len(want) {
+			srv.Health.SetServingStatus(ServiceName, want[i+1])
+		}
+	}
+}
+
+func TestRunHealth(t *testing.T) {
+	var healthy atomic.Value
+	healthy.Store(true)
+	srv := NewServer(WithHealthCheck(ServiceName, 10*time.Millisecond, func(ctx context.Context) error {
+		if !healthy.Load().(bool) {
+			return errors.New("dependency down")
+		}
+		return nil
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		_, err := srv.Run(ctx, bufconn.Listen(1024*1024))
+		done <- err
+	}()
+
+	check := func(want healthpb.HealthCheckResponse_ServingStatus) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			res, err := srv.Health.Check(context.Background(), &healthpb.HealthCheckRequest{Service: ServiceName})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.Status == want {
+				return
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("status = %v, want %v", res.Status, want)
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+
+	check(healthpb.HealthCheckResponse_SERVING)
+	healthy.Store(false)
+	check(healthpb.HealthCheckResponse_NOT_SERVING)
+	healthy.Store(true)
+	check(healthpb.HealthCheckResponse_SERVING)
+
+	// 退出后保持 NOT_SERVING
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	check(healthpb.HealthCheckResponse_NOT_SERVING)
+}
+
+func TestRunCloseStreams(t *testing.T) {
+	srv := NewServer()
+	lis := bufconn.Listen(1024 * 1024)
+	ctx, cancel := context.WithCancel(context.Background())
+	type result struct {
+		report lifecycle.Report
+		err    error
+	}
+	resc := make(chan result, 1)
+	go func() {
+		report, err := srv.Run(ctx, lis, lifecycle.WithDrainTimeout(5*time.Second))
+		resc <- result{report, err}
+	}()
+
+	conn, err := grpc.NewClient(pingtest.Target,
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	// 保持打开的 health Watch 和反射流
+	streamCtx, streamCancel := context.WithCancel(context.Background())
+	defer streamCancel()
+	watch, err := healthpb.NewHealthClient(conn).Watch(streamCtx, &healthpb.HealthCheckRequest{Service: ServiceName})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res, err := watch.Recv(); err != nil || res.Status != healthpb.HealthCheckResponse_SERVING {
+		t.Fatalf("watch = %v, %v, want SERVING", res, err)
+	}
+	if _, err := listServicesV1(streamCtx, conn); err != nil {
+		t.Fatal(err)
+	}
+
+	cancel()
+	res := <-resc
+	if res.err != nil {
+		t.Fatal(res.err)
+	}
+	if !res.report.Graceful || res.report.AbortedStreams != 0 {
+		t.Errorf("report = %v, want graceful with no aborted streams", res.report)
+	}
+	if res.report.Duration >= time.Second {
+		t.Errorf("duration = %v, want streams closed before draining", res.report.Duration)
+	}
+	// 客户端收到 NOT_SERVING 或流结束
+	for {
+		res, err := watch.Recv()
+		if err != nil {
+			if status.Code(err) != codes.Unavailable {
+				t.Errorf("watch code = %v, want %v", status.Code(err), codes.Unavailable)
+			}
+			break
+		}
+		if res.Status != healthpb.HealthCheckResponse_NOT_SERVING {
+			t.Errorf("watch status = %v, want NOT_SERVING", res.Status)
+		}
+	}
+}
@@ -0,0 +1,468 @@
+// Package pingpong 提供可配置的 PingPong 服务实现
+//
+// 各章节示例和业务服务共用同一份实现，通过 Option 调整流消息数量、metadata 等行为。
+package pingpong
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "github.com/jergoo/go-grpc-tutorial/protos/ping" // 引入编译生成的包
+	"github.com/jergoo/go-grpc-tutorial/protos/validate"
+	"github.com/jergoo/go-grpc-tutorial/rpcerr"
+)
+
+// 默认配置
+const (
+	DefaultPongCount    = 10 // MultiPong 响应消息数
+	DefaultMultiPingMax = 5  // MultiPing 最多接收的消息数
+	DefaultBatchSize    = 2  // MultiPingPong 每收到多少个消息响应一次
+)
+
+// 请求中 StreamOptions 允许的最大值，读取自 ping.proto 中声明的字段校验规则
+var (
+	MaxReplyCount    = uint32(streamOptionRules("reply_count").GetLte())                 // MultiPong 响应消息数
+	MaxReplyInterval = streamOptionRules("reply_interval").GetDurationLte().AsDuration() // MultiPong 响应发送间隔
+	MaxPayloadSize   = uint32(streamOptionRules("payload_size").GetLte())                // MultiPong 每条响应的负载字节数
+	MaxBatchSize     = uint32(streamOptionRules("batch_size").GetLte())                  // MultiPingPong 每收到多少个消息响应一次
+	MaxMessages      = uint32(streamOptionRules("max_messages").GetLte())                // MultiPing、MultiPingPong 最多接收的消息数
+)
+
+// streamOptionRules 返回 StreamOptions 字段在 ping.proto 中声明的校验规则
+func streamOptionRules(name protoreflect.Name) *validate.FieldRules {
+	fd := (&pb.StreamOptions{}).ProtoReflect().Descriptor().Fields().ByName(name)
+	rules, _ := proto.GetExtension(fd.Options(), validate.E_Rules).(*validate.FieldRules)
+	return rules
+}
+
+// MetadataFunc 根据请求上下文生成响应 metadata，method 为完整方法名，如 /protos.PingPong/Ping
+type MetadataFunc func(ctx context.Context, method string) metadata.MD
+
+// Option PingPongServer 配置项
+type Option func(*PingPongServer)
+
+// WithPongCount 设置 MultiPong 响应消息数
+func WithPongCount(n int) Option {
+	return func(s *PingPongServer) {
+		if n < 0 {
+			n = 0
+		}
+		s.pongCount = n
+	}
+}
+
+// WithMultiPingMax 设置 MultiPing 最多接收的消息数，超出后返回 codes.ResourceExhausted，n <= 0 不限制
+func WithMultiPingMax(n int) Option {
+	return func(s *PingPongServer) {
+		s.multiPingMax = n
+	}
+}
+
+// WithBatchSize 设置 MultiPingPong 每收到 n 个消息响应一次
+func WithBatchSize(n int) Option {
+	return func(s *PingPongServer) {
+		if n < 1 {
+			n = 1
+		}
+		s.batchSize = n
+	}
+}
+
+// WithIdleTimeout 设置 MultiPing、MultiPingPong 的空闲超时，超过 d 没有收到消息时返回 codes.DeadlineExceeded，d <= 0 不限制
+func WithIdleTimeout(d time.Duration) Option {
+	return func(s *PingPongServer) {
+		s.idleTimeout = d
+	}
+}
+
+// WithHeaderFunc 设置响应 header metadata
+func WithHeaderFunc(fn MetadataFunc) Option {
+	return func(s *PingPongServer) {
+		s.header = fn
+	}
+}
+
+// WithTrailerFunc 设置响应 trailer metadata
+func WithTrailerFunc(fn MetadataFunc) Option {
+	return func(s *PingPongServer) {
+		s.trailer = fn
+	}
+}
+
+// PingPongServer 实现 pb.PingPongServer 接口
+type PingPongServer struct {
+	pb.UnimplementedPingPongServer // 兼容性需要，避免未实现server接口全部方法
+
+	pongCount    int
+	multiPingMax int
+	batchSize    int
+	idleTimeout  time.Duration
+	replay       *replayBuffer
+	header       MetadataFunc
+	trailer      MetadataFunc
+}
+
+// NewPingPongServer 创建 PingPongServer
+func NewPingPongServer(opts ...Option) *PingPongServer {
+	s := &PingPongServer{
+		pongCount:    DefaultPongCount,
+		multiPingMax: DefaultMultiPingMax,
+		batchSize:    DefaultBatchSize,
+		replay:       newReplayBuffer(),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Ping 单次请求-响应模式，返回请求序号和服务端收发时间，用于客户端计算延迟
+func (s *PingPongServer) Ping(ctx context.Context, req *pb.PingRequest) (*pb.PongResponse, error) {
+	receivedAt := timestamppb.Now()
+	if err := s.setMetadata(ctx); err != nil {
+		return nil, err
+	}
+	return &pb.PongResponse{
+		Value:      "pong",
+		Seq:        req.Seq,
+		ReceivedAt: receivedAt,
+		SentAt:     timestamppb.Now(),
+	}, nil
+}
+
+// MultiPong 服务端流模式，请求的 options 可以指定响应消息数、间隔、负载大小和响应内容
+//
+// 通过 WithReplay 开启断点续传后每条响应携带 resume_token，流中断后客户端在请求中携带最后收到的 resume_token 重新调用，
+// 从中断的位置继续。
+func (s *PingPongServer) MultiPong(req *pb.PingRequest, stream pb.PingPong_MultiPongServer) error {
+	var (
+		rs       *replayStream
+		gen, seq uint64 // seq 为客户端已收到的位置
+	)
+	if req.ResumeToken != "" {
+		var err error
+		if rs, gen, seq, err = s.replay.resume(req.ResumeToken); err != nil {
+			return err
+		}
+	} else {
+		cfg, err := s.newStreamConfig(req.Options, 0)
+		if err != nil {
+			return err
+		}
+		value := "pong"
+		if cfg.echo {
+			value = req.Value
+		}
+		rs, gen = s.replay.create(cfg, value)
+	}
+	defer s.replay.release(rs, gen)
+	if err := s.setStreamMetadata(stream); err != nil {
+		return err
+	}
+	// 每条响应共用同一份负载，补发时重新生成
+	payload := make([]byte, rs.cfg.payloadSize)
+
+	ctx := stream.Context()
+	for {
+		// 客户端取消或超时后不再发送
+		if err := ctx.Err(); err != nil {
+			return status.FromContextError(err).Err()
+		}
+		data, fresh, err := s.replay.next(rs, gen, seq+1)
+		if err != nil || data == nil {
+			return err
+		}
+		data.Payload = payload
+		// 发送消息
+		if err := stream.Send(data); err != nil {
+			return err
+		}
+		seq = data.Seq
+		// 补发的消息不等待间隔
+		if fresh && rs.cfg.replyInterval > 0 && seq < uint64(rs.cfg.replyCount) {
+			if err := sleep(ctx, rs.cfg.replyInterval); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// MultiPing 客户端流模式，第一条消息的 options 可以指定最多接收的消息数、超出后的处理方式和响应内容
+func (s *PingPongServer) MultiPing(stream pb.PingPong_MultiPingServer) error {
+	if err := s.setStreamMetadata(stream); err != nil {
+		return err
+	}
+
+	var cfg streamConfig
+	recv := s.receiver(stream)
+	msgs := []string{}
+	var received uint64 // 收到的负载字节数
+	reply := func() error {
+		value := fmt.Sprintf("got %d ping", len(msgs))
+		if cfg.echo {
+			value = strings.Join(msgs, ",")
+		}
+		return stream.SendAndClose(&pb.PongResponse{
+			Value:         value,
+			Received:      uint64(len(msgs)),
+			ReceivedBytes: received,
+		})
+	}
+	for {
+		msg, err := recv()
+		if err != nil {
+			// 客户端消息结束，返回响应信息
+			if err == io.EOF {
+				return reply()
+			}
+			return err
+		}
+		if len(msgs) == 0 {
+			if cfg, err = s.newStreamConfig(msg.Options, s.multiPingMax); err != nil {
+				return err
+			}
+		}
+		// 超过最多接收的消息数，提前结束
+		if cfg.maxMessages > 0 && len(msgs) >= cfg.maxMessages {
+			if cfg.closeOnLimit {
+				return reply()
+			}
+			return limitExceeded("MultiPing", cfg.maxMessages)
+		}
+		msgs = append(msgs, msg.Value)
+		received += uint64(len(msg.Payload))
+	}
+}
+
+// MultiPingPong 双向流模式，第一条消息的 options 可以指定每批消息数、最多接收的消息数和响应内容
+func (s *PingPongServer) MultiPingPong(stream pb.PingPong_MultiPingPongServer) error {
+	if err := s.setStreamMetadata(stream); err != nil {
+		return err
+	}
+
+	var cfg streamConfig
+	recv := s.receiver(stream)
+	n := 0
+	batch := []string{}
+	for {
+		// 接收消息
+		msg, err := recv()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return err
+		}
+		if n == 0 {
+			if cfg, err = s.newStreamConfig(msg.Options, 0); err != nil {
+				return err
+			}
+		}
+		if cfg.maxMessages > 0 && n >= cfg.maxMessages {
+			if cfg.closeOnLimit {
+				return nil
+			}
+			return limitExceeded("MultiPingPong", cfg.maxMessages)
+		}
+		n++
+		batch = append(batch, msg.Value)
+
+		// 每收到 batchSize 个消息响应一次
+		if len(batch) == cfg.batchSize {
+			value := "pong"
+			if cfg.echo {
+				value = strings.Join(batch, ",")
+			}
+			err = stream.Send(&pb.PongResponse{Value: value})
+			if err != nil {
+				return err
+			}
+			batch = batch[:0]
+		}
+	}
+	return nil
+}
+
+// limitExceeded 超过最多接收的消息数时返回的错误
+func limitExceeded(method string, max int) error {
+	return rpcerr.Newf(codes.ResourceExhausted, "ping enough, max %d", max).
+		WithReason(rpcerr.ReasonPingLimitExceeded, map[string]string{"max": strconv.Itoa(max)}).
+		WithQuotaViolation(method, fmt.Sprintf("at most %d messages per stream", max)).
+		WithLocalizedMessage("zh-CN", fmt.Sprintf("每次最多发送 %d 条消息", max)).
+		Err()
+}
+
+// streamConfig 服务端默认配置与请求 StreamOptions 合并后的流配置
+type streamConfig struct {
+	replyCount    int
+	replyInterval time.Duration
+	payloadSize   int
+	batchSize     int
+	echo          bool
+	maxMessages   int // <= 0 不限制
+	closeOnLimit  bool
+}
+
+// newStreamConfig 校验并合并请求的 StreamOptions，maxMessages 为未指定时最多接收的消息数
+func (s *PingPongServer) newStreamConfig(o *pb.StreamOptions, maxMessages int) (streamConfig, error) {
+	cfg := streamConfig{
+		replyCount:  s.pongCount,
+		batchSize:   s.batchSize,
+		maxMessages: maxMessages,
+	}
+	if err := validateStreamOptions(o); err != nil {
+		return cfg, err
+	}
+	if o.GetReplyCount() > 0 {
+		cfg.replyCount = int(o.GetReplyCount())
+	}
+	if o.GetBatchSize() > 0 {
+		cfg.batchSize = int(o.GetBatchSize())
+	}
+	if o.GetMaxMessages() > 0 {
+		cfg.maxMessages = int(o.GetMaxMessages())
+	}
+	cfg.replyInterval = o.GetReplyInterval().AsDuration()
+	cfg.payloadSize = int(o.GetPayloadSize())
+	cfg.echo = o.GetReplyMode() == pb.StreamOptions_REPLY_MODE_ECHO
+	cfg.closeOnLimit = o.GetTermination() == pb.StreamOptions_TERMINATION_CLOSE
+	return cfg, nil
+}
+
+// validateStreamOptions 检查 StreamOptions 是否超出 ping.proto 中声明的范围，返回包含全部错误字段的 codes.InvalidArgument
+func validateStreamOptions(o *pb.StreamOptions) error {
+	if o == nil {
+		return nil
+	}
+	var violations [][2]string
+	check := func(ok bool, field, desc string) {
+		if !ok {
+			violations = append(violations, [2]string{"options." + field, desc})
+		}
+	}
+	check(o.ReplyCount <= MaxReplyCount, "reply_count", fmt.Sprintf("must be at most %d", MaxReplyCount))
+	if o.ReplyInterval != nil {
+		d := o.ReplyInterval.AsDuration()
+		check(o.ReplyInterval.IsValid() && d >= 0 && d <= MaxReplyInterval, "reply_interval", fmt.Sprintf("must be between 0 and %s", MaxReplyInterval))
+	}
+	check(o.PayloadSize <= MaxPayloadSize, "payload_size", fmt.Sprintf("must be at most %d", MaxPayloadSize))
+	check(o.BatchSize <= MaxBatchSize, "batch_size", fmt.Sprintf("must be at most %d", MaxBatchSize))
+	check(o.MaxMessages <= MaxMessages, "max_messages", fmt.Sprintf("must be at most %d", MaxMessages))
+	_, ok := pb.StreamOptions_ReplyMode_name[int32(o.ReplyMode)]
+	check(ok, "reply_mode", "must be a defined ReplyMode value")
+	_, ok = pb.StreamOptions_Termination_name[int32(o.Termination)]
+	check(ok, "termination", "must be a defined Termination value")
+	if len(violations) == 0 {
+		return nil
+	}
+
+	b := rpcerr.New(codes.InvalidArgument, "invalid stream options").WithReason(rpcerr.ReasonInvalidRequest, nil)
+	for _, v := range violations {
+		b = b.WithFieldViolation(v[0], v[1])
+	}
+	return b.Err()
+}
+
+// receiver 返回接收请求消息的函数
+//
+// 设置了空闲超时时在单独的 goroutine 中接收消息，等待超过 idleTimeout 返回 codes.DeadlineExceeded。
+// 方法返回后流的 context 被取消，进行中的接收随之结束，goroutine 退出。
+func (s *PingPongServer) receiver(stream grpc.ServerStream) func() (*pb.PingRequest, error) {
+	recv := func() (*pb.PingRequest, error) {
+		msg := new(pb.PingRequest)
+		if err := stream.RecvMsg(msg); err != nil {
+			return nil, err
+		}
+		return msg, nil
+	}
+	if s.idleTimeout <= 0 {
+		return recv
+	}
+
+	type result struct {
+		msg *pb.PingRequest
+		err error
+	}
+	ctx := stream.Context()
+	results := make(chan result)
+	go func() {
+		for {
+			msg, err := recv()
+			select {
+			case results <- result{msg, err}:
+			case <-ctx.Done():
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+	return func() (*pb.PingRequest, error) {
+		timer := time.NewTimer(s.idleTimeout)
+		defer timer.Stop()
+		select {
+		case r := <-results:
+			return r.msg, r.err
+		case <-timer.C:
+			return nil, status.Errorf(codes.DeadlineExceeded, "stream idle for %s", s.idleTimeout)
+		case <-ctx.Done():
+			return nil, status.FromContextError(ctx.Err()).Err()
+		}
+	}
+}
+
+// sleep 等待 d，流被取消时返回对应状态的错误
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return status.FromContextError(ctx.Err()).Err()
+	}
+}
+
+// setMetadata 设置单次请求的响应 metadata
+func (s *PingPongServer) setMetadata(ctx context.Context) error {
+	method, _ := grpc.Method(ctx)
+	if s.header != nil {
+		if err := grpc.SetHeader(ctx, s.header(ctx, method)); err != nil {
+			return err
+		}
+	}
+	if s.trailer != nil {
+		if err := grpc.SetTrailer(ctx, s.trailer(ctx, method)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setStreamMetadata 设置流的响应 metadata，header 立即发送
+func (s *PingPongServer) setStreamMetadata(stream grpc.ServerStream) error {
+	ctx := stream.Context()
+	method, _ := grpc.Method(ctx)
+	if s.header != nil {
+		if err := stream.SendHeader(s.header(ctx, method)); err != nil {
+			return err
+		}
+	}
+	if s.trailer != nil {
+		stream.SetTrailer(s.trailer(ctx, method))
+	}
+	return nil
+}
@@ -0,0 +1,683 @@
+package pingpong
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/goleak"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/jergoo/go-grpc-tutorial/pingtest"
+	pb "github.com/jergoo/go-grpc-tutorial/protos/ping" // 引入编译生成的包
+	"github.com/jergoo/go-grpc-tutorial/rpcerr"
+)
+
+func TestPing(t *testing.T) {
+	srv := pingtest.NewServer(t, NewPingPongServer(
+		WithHeaderFunc(func(ctx context.Context, method string) metadata.MD {
+			return metadata.Pairs("method", method)
+		}),
+		WithTrailerFunc(func(ctx context.Context, method string) metadata.MD {
+			return metadata.Pairs("trailer", "t")
+		}),
+	))
+
+	var header, trailer metadata.MD
+	res, err := srv.Client.Ping(context.Background(), &pb.PingRequest{Value: "ping", Seq: 7}, grpc.Header(&header), grpc.Trailer(&trailer))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Value != "pong" {
+		t.Errorf("got %q, want %q", res.Value, "pong")
+	}
+	if res.Seq != 7 {
+		t.Errorf("seq = %d, want 7", res.Seq)
+	}
+	if res.ReceivedAt == nil || res.SentAt == nil || res.SentAt.AsTime().Before(res.ReceivedAt.AsTime()) {
+		t.Errorf("received at %v, sent at %v", res.ReceivedAt, res.SentAt)
+	}
+	if got := header.Get("method"); len(got) != 1 || got[0] != "/protos.PingPong/Ping" {
+		t.Errorf("header method = %v", got)
+	}
+	if got := trailer.Get("trailer"); len(got) != 1 || got[0] != "t" {
+		t.Errorf("trailer = %v", got)
+	}
+}
+
+// TestStreamOptionLimits 上限读取自 ping.proto 中的字段校验规则
+func TestStreamOptionLimits(t *testing.T) {
+	got := []interface{}{MaxReplyCount, MaxReplyInterval, MaxPayloadSize, MaxBatchSize, MaxMessages}
+	want := []interface{}{uint32(1000000), time.Minute, uint32(1 << 20), uint32(1000), uint32(1000000)}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("limits = %v, want %v", got, want)
+	}
+}
+
+func TestMultiPong(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    []Option
+		req     *pb.PingRequest
+		want    int
+		value   string
+		size    int
+		wantErr codes.Code
+	}{
+		{name: "default", req: &pb.PingRequest{Value: "ping"}, want: DefaultPongCount, value: "pong"},
+		{name: "3 pongs", opts: []Option{WithPongCount(3)}, req: &pb.PingRequest{Value: "ping"}, want: 3, value: "pong"},
+		{name: "no pong", opts: []Option{WithPongCount(-1)}, req: &pb.PingRequest{Value: "ping"}, want: 0},
+		{
+			name: "request options",
+			opts: []Option{WithPongCount(3)},
+			req: &pb.PingRequest{Value: "ping", Options: &pb.StreamOptions{
+				ReplyCount:    4,
+				PayloadSize:   1024,
+				ReplyInterval: durationpb.New(time.Millisecond),
+			}},
+			want: 4, value: "pong", size: 1024,
+		},
+		{
+			name: "echo",
+			req:  &pb.PingRequest{Value: "hello", Options: &pb.StreamOptions{ReplyCount: 2, ReplyMode: pb.StreamOptions_REPLY_MODE_ECHO}},
+			want: 2, value: "hello",
+		},
+		{
+			name:    "payload too large",
+			req:     &pb.PingRequest{Value: "ping", Options: &pb.StreamOptions{PayloadSize: MaxPayloadSize + 1}},
+			wantErr: codes.InvalidArgument,
+		},
+		{
+			name:    "interval too long",
+			req:     &pb.PingRequest{Value: "ping", Options: &pb.StreamOptions{ReplyInterval: durationpb.New(time.Hour)}},
+			wantErr: codes.InvalidArgument,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			srv := pingtest.NewServer(t, NewPingPongServer(tt.opts...))
+			stream, err := srv.Client.MultiPong(context.Background(), tt.req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := 0
+			for {
+				res, err := stream.Recv()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					if status.Code(err) != tt.wantErr {
+						t.Fatal(err)
+					}
+					return
+				}
+				got++
+				if res.Seq != uint64(got) || res.Value != tt.value || len(res.Payload) != tt.size {
+					t.Errorf("seq = %d, value = %q, payload = %d", res.Seq, res.Value, len(res.Payload))
+				}
+			}
+			if tt.wantErr != codes.OK {
+				t.Fatalf("want %s", tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got %d pongs, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMultiPongCancel(t *testing.T) {
+	srv := pingtest.NewServer(t, NewPingPongServer())
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	stream, err := srv.Client.MultiPong(ctx, &pb.PingRequest{Value: "ping", Options: &pb.StreamOptions{
+		ReplyCount:    2,
+		ReplyInterval: durationpb.New(MaxReplyInterval),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 第一条消息立即发送，第二条等待间隔时被取消
+	if _, err := stream.Recv(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stream.Recv(); status.Code(err) != codes.DeadlineExceeded {
+		t.Errorf("err = %v", err)
+	}
+}
+
+// recvPongs 接收 n 条响应后取消调用，n < 0 时接收到流结束
+func recvPongs(t *testing.T, client pb.PingPongClient, req *pb.PingRequest, n int) ([]*pb.PongResponse, error) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream, err := client.MultiPong(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var msgs []*pb.PongResponse
+	for n < 0 || len(msgs) < n {
+		res, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return msgs, err
+		}
+		msgs = append(msgs, res)
+	}
+	return msgs, nil
+}
+
+func TestMultiPongResume(t *testing.T) {
+	tokenOf := func(seq int) func([]*pb.PongResponse) string {
+		return func(msgs []*pb.PongResponse) string { return msgs[seq-1].ResumeToken }
+	}
+	constToken := func(token string) func([]*pb.PongResponse) string {
+		return func([]*pb.PongResponse) string { return token }
+	}
+	tests := []struct {
+		name    string
+		opts    []Option
+		first   int           // 第一次调用接收的消息数，-1 接收到流结束
+		wait    time.Duration // 继续前等待的时间
+		token   func(msgs []*pb.PongResponse) string
+		want    []uint64 // 继续后收到的 seq
+		wantErr codes.Code
+		noToken bool // 不开启断点续传，响应不携带 resume_token
+	}{
+		{name: "resume mid-stream", first: 2, token: tokenOf(2), want: []uint64{3, 4, 5}},
+		{name: "replay after stream end", first: -1, token: tokenOf(2), want: []uint64{3, 4, 5}},
+		{name: "resume at end", first: -1, token: tokenOf(5)},
+		{name: "position evicted", opts: []Option{WithReplay(2, time.Minute)}, first: -1, token: tokenOf(1), wantErr: codes.FailedPrecondition},
+		{name: "position retained", opts: []Option{WithReplay(2, time.Minute)}, first: -1, token: tokenOf(3), want: []uint64{4, 5}},
+		{name: "expired", opts: []Option{WithReplay(10, 10*time.Millisecond)}, first: -1, wait: 50 * time.Millisecond, token: tokenOf(2), wantErr: codes.FailedPrecondition},
+		{name: "unknown stream", first: 1, token: constToken("abc-1"), wantErr: codes.FailedPrecondition},
+		{name: "malformed token", first: 1, token: constToken("abc"), wantErr: codes.InvalidArgument},
+		{name: "disabled by default", first: -1, token: constToken("abc-1"), wantErr: codes.FailedPrecondition, noToken: true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			opts := tt.opts
+			if !tt.noToken {
+				opts = append([]Option{WithReplay(DefaultReplaySize, DefaultReplayTTL)}, opts...)
+			}
+			srv := pingtest.NewServer(t, NewPingPongServer(opts...))
+			req := &pb.PingRequest{Value: "hello", Options: &pb.StreamOptions{ReplyCount: 5, ReplyMode: pb.StreamOptions_REPLY_MODE_ECHO, PayloadSize: 8}}
+			msgs, err := recvPongs(t, srv.Client, req, tt.first)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, msg := range msgs {
+				if (msg.ResumeToken == "") != tt.noToken {
+					t.Fatalf("seq %d resume token = %q", msg.Seq, msg.ResumeToken)
+				}
+			}
+			time.Sleep(tt.wait)
+
+			// 继续时忽略请求的其他字段，沿用第一次调用的配置
+			resumed, err := recvPongs(t, srv.Client, &pb.PingRequest{Value: "other", ResumeToken: tt.token(msgs)}, -1)
+			if status.Code(err) != tt.wantErr {
+				t.Fatalf("err = %v, want %s", err, tt.wantErr)
+			}
+			if e, _ := rpcerr.FromError(err); tt.wantErr == codes.FailedPrecondition && !errors.Is(e, rpcerr.ErrResumeUnavailable) {
+				t.Errorf("err = %v, want %v", err, rpcerr.ErrResumeUnavailable)
+			}
+			var got []uint64
+			for _, msg := range resumed {
+				got = append(got, msg.Seq)
+				if msg.Value != "hello" || len(msg.Payload) != 8 {
+					t.Errorf("seq %d value = %q, payload = %d", msg.Seq, msg.Value, len(msg.Payload))
+				}
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("resumed seq = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMultiPongResumeMaxStreams(t *testing.T) {
+	srv := pingtest.NewServer(t, NewPingPongServer(WithReplay(DefaultReplaySize, DefaultReplayTTL), WithReplayMaxStreams(2)))
+	req := &pb.PingRequest{Value: "ping", Options: &pb.StreamOptions{ReplyCount: 2}}
+	var tokens []string
+	for i := 0; i < 3; i++ {
+		msgs, err := recvPongs(t, srv.Client, req, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tokens = append(tokens, msgs[0].ResumeToken)
+	}
+
+	// 超出上限后淘汰最早创建的流
+	for i, token := range tokens {
+		_, err := recvPongs(t, srv.Client, &pb.PingRequest{Value: "ping", ResumeToken: token}, -1)
+		if want := i == 0; (status.Code(err) == codes.FailedPrecondition) != want {
+			t.Errorf("stream %d err = %v", i, err)
+		}
+	}
+}
+
+func TestReplaySweep(t *testing.T) {
+	b := newReplayBuffer()
+	b.size, b.ttl = DefaultReplaySize, 10*time.Millisecond
+	rs, gen := b.create(streamConfig{replyCount: 1}, "pong")
+	b.release(rs, gen)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		b.run(ctx)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		b.mu.Lock()
+		n := len(b.streams) + b.order.Len()
+		b.mu.Unlock()
+		if n == 0 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d streams retained after ttl", n)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestMultiPongResumeTakeover(t *testing.T) {
+	srv := pingtest.NewServer(t, NewPingPongServer(WithReplay(DefaultReplaySize, DefaultReplayTTL)))
+	stream, err := srv.Client.MultiPong(context.Background(), &pb.PingRequest{Value: "ping", Options: &pb.StreamOptions{
+		ReplyCount:    3,
+		ReplyInterval: durationpb.New(100 * time.Millisecond),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, err := stream.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 原调用还未结束时继续，由新的调用接管发送
+	resumed, err := recvPongs(t, srv.Client, &pb.PingRequest{Value: "ping", ResumeToken: first.ResumeToken}, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resumed) != 2 || resumed[0].Seq != 2 || resumed[1].Seq != 3 {
+		t.Errorf("resumed = %v", resumed)
+	}
+	for {
+		if _, err = stream.Recv(); err != nil {
+			break
+		}
+	}
+	if status.Code(err) != codes.Aborted {
+		t.Errorf("original stream err = %v, want %s", err, codes.Aborted)
+	}
+}
+
+func TestMultiPing(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []Option
+		options  *pb.StreamOptions // 第一条消息携带的配置
+		send     int
+		want     string
+		received int
+		wantMax  string // 超过上限时错误详情中的 max
+	}{
+		{name: "under max", send: 3, want: "got 3 ping"},
+		{name: "at max", send: 5, want: "got 5 ping"},
+		{name: "over max", send: 10, wantMax: "5"},
+		{name: "custom max", opts: []Option{WithMultiPingMax(1)}, send: 3, wantMax: "1"},
+		{name: "unlimited", opts: []Option{WithMultiPingMax(0)}, send: 10, want: "got 10 ping"},
+		{name: "request max", options: &pb.StreamOptions{MaxMessages: 10}, send: 10, want: "got 10 ping"},
+		{name: "request max exceeded", options: &pb.StreamOptions{MaxMessages: 2}, send: 3, wantMax: "2"},
+		{
+			name:    "close on limit",
+			options: &pb.StreamOptions{MaxMessages: 2, Termination: pb.StreamOptions_TERMINATION_CLOSE},
+			send:    5, want: "got 2 ping", received: 2,
+		},
+		{name: "echo", options: &pb.StreamOptions{ReplyMode: pb.StreamOptions_REPLY_MODE_ECHO}, send: 3, want: "p0,p1,p2"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			srv := pingtest.NewServer(t, NewPingPongServer(tt.opts...))
+			stream, err := srv.Client.MultiPing(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := 0; i < tt.send; i++ {
+				// 服务端提前结束后发送返回 io.EOF，错误通过 CloseAndRecv 获取
+				req := &pb.PingRequest{Value: fmt.Sprintf("p%d", i), Payload: make([]byte, 10)}
+				if i == 0 {
+					req.Options = tt.options
+				}
+				if err := stream.Send(req); err != nil {
+					break
+				}
+			}
+			res, err := stream.CloseAndRecv()
+			if tt.wantMax != "" {
+				e, _ := rpcerr.FromError(err)
+				if !errors.Is(e, rpcerr.ErrPingLimitExceeded) {
+					t.Fatalf("err = %v", err)
+				}
+				if e.Metadata["max"] != tt.wantMax || len(e.QuotaViolations) != 1 || e.LocalizedMessage == "" {
+					t.Errorf("details = %+v", e)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.Value != tt.want {
+				t.Errorf("got %q, want %q", res.Value, tt.want)
+			}
+			received := tt.received
+			if received == 0 {
+				received = tt.send
+			}
+			if res.Received != uint64(received) || res.ReceivedBytes != uint64(received*10) {
+				t.Errorf("received = %d, bytes = %d", res.Received, res.ReceivedBytes)
+			}
+		})
+	}
+}
+
+func TestMultiPingPong(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    []Option
+		options *pb.StreamOptions // 第一条消息携带的配置
+		send    int
+		want    []string
+		wantErr codes.Code
+	}{
+		{name: "default", send: 6, want: []string{"pong", "pong", "pong"}},
+		{name: "batch 3", opts: []Option{WithBatchSize(3)}, send: 7, want: []string{"pong", "pong"}},
+		{name: "batch 1", opts: []Option{WithBatchSize(0)}, send: 4, want: []string{"pong", "pong", "pong", "pong"}},
+		{name: "request batch", options: &pb.StreamOptions{BatchSize: 4}, send: 8, want: []string{"pong", "pong"}},
+		{
+			name:    "echo",
+			options: &pb.StreamOptions{BatchSize: 2, ReplyMode: pb.StreamOptions_REPLY_MODE_ECHO},
+			send:    5, want: []string{"p0,p1", "p2,p3"},
+		},
+		{
+			name:    "max exceeded",
+			options: &pb.StreamOptions{BatchSize: 1, MaxMessages: 2},
+			send:    3, want: []string{"pong", "pong"}, wantErr: codes.ResourceExhausted,
+		},
+		{
+			name:    "close on limit",
+			options: &pb.StreamOptions{BatchSize: 1, MaxMessages: 2, Termination: pb.StreamOptions_TERMINATION_CLOSE},
+			send:    3, want: []string{"pong", "pong"},
+		},
+		{name: "invalid batch", options: &pb.StreamOptions{BatchSize: MaxBatchSize + 1}, send: 1, wantErr: codes.InvalidArgument},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			srv := pingtest.NewServer(t, NewPingPongServer(tt.opts...))
+			stream, err := srv.Client.MultiPingPong(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := 0; i < tt.send; i++ {
+				req := &pb.PingRequest{Value: fmt.Sprintf("p%d", i)}
+				if i == 0 {
+					req.Options = tt.options
+				}
+				// 服务端提前结束后发送返回 io.EOF
+				if err := stream.Send(req); err != nil {
+					break
+				}
+			}
+			stream.CloseSend()
+			var got []string
+			for {
+				res, err := stream.Recv()
+				if err == io.EOF {
+					err = nil
+				}
+				if err != nil || res == nil {
+					if status.Code(err) != tt.wantErr {
+						t.Fatalf("err = %v, want %s", err, tt.wantErr)
+					}
+					break
+				}
+				got = append(got, res.Value)
+			}
+			if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// handlerErrors 记录服务端方法返回的错误
+func handlerErrors() (grpc.StreamServerInterceptor, <-chan error) {
+	errs := make(chan error, 1)
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		err := handler(srv, ss)
+		errs <- err
+		return err
+	}, errs
+}
+
+func TestStreamCancellation(t *testing.T) {
+	tests := []struct {
+		name       string
+		opts       []Option
+		timeout    time.Duration // 客户端调用超时，0 不设置
+		call       func(ctx context.Context, cancel context.CancelFunc, client pb.PingPongClient) error
+		clientCode codes.Code
+		serverCode []codes.Code // 超时时客户端同时取消流，服务端可能先观察到取消
+	}{
+		{
+			name: "multi pong cancel mid-stream",
+			call: func(ctx context.Context, cancel context.CancelFunc, client pb.PingPongClient) error {
+				// 服务端持续发送，客户端收到一条后取消
+				stream, err := client.MultiPong(ctx, &pb.PingRequest{Value: "ping", Options: &pb.StreamOptions{ReplyCount: MaxReplyCount}})
+				if err != nil {
+					return err
+				}
+				if _, err := stream.Recv(); err != nil {
+					return err
+				}
+				cancel()
+				for {
+					if _, err := stream.Recv(); err != nil {
+						return err
+					}
+				}
+			},
+			clientCode: codes.Canceled, serverCode: []codes.Code{codes.Canceled},
+		},
+		{
+			name:    "multi pong deadline exceeded",
+			timeout: 50 * time.Millisecond,
+			call: func(ctx context.Context, cancel context.CancelFunc, client pb.PingPongClient) error {
+				stream, err := client.MultiPong(ctx, &pb.PingRequest{Value: "ping", Options: &pb.StreamOptions{
+					ReplyCount:    2,
+					ReplyInterval: durationpb.New(MaxReplyInterval),
+				}})
+				if err != nil {
+					return err
+				}
+				for {
+					if _, err := stream.Recv(); err != nil {
+						return err
+					}
+				}
+			},
+			clientCode: codes.DeadlineExceeded, serverCode: []codes.Code{codes.DeadlineExceeded, codes.Canceled},
+		},
+		{
+			name: "multi ping cancel while server receiving",
+			call: func(ctx context.Context, cancel context.CancelFunc, client pb.PingPongClient) error {
+				stream, err := client.MultiPing(ctx)
+				if err != nil {
+					return err
+				}
+				if err := stream.Send(&pb.PingRequest{Value: "ping"}); err != nil {
+					return err
+				}
+				time.AfterFunc(20*time.Millisecond, cancel)
+				return stream.RecvMsg(new(pb.PongResponse))
+			},
+			clientCode: codes.Canceled, serverCode: []codes.Code{codes.Canceled},
+		},
+		{
+			name: "multi ping idle timeout",
+			opts: []Option{WithIdleTimeout(50 * time.Millisecond)},
+			call: func(ctx context.Context, cancel context.CancelFunc, client pb.PingPongClient) error {
+				// 发送一条后不再发送也不结束
+				stream, err := client.MultiPing(ctx)
+				if err != nil {
+					return err
+				}
+				if err := stream.Send(&pb.PingRequest{Value: "ping"}); err != nil {
+					return err
+				}
+				return stream.RecvMsg(new(pb.PongResponse))
+			},
+			clientCode: codes.DeadlineExceeded, serverCode: []codes.Code{codes.DeadlineExceeded},
+		},
+		{
+			name: "multi ping half-closed",
+			opts: []Option{WithIdleTimeout(time.Second)},
+			call: func(ctx context.Context, cancel context.CancelFunc, client pb.PingPongClient) error {
+				stream, err := client.MultiPing(ctx)
+				if err != nil {
+					return err
+				}
+				if err := stream.Send(&pb.PingRequest{Value: "ping"}); err != nil {
+					return err
+				}
+				_, err = stream.CloseAndRecv()
+				return err
+			},
+		},
+		{
+			name: "multi ping pong idle timeout",
+			opts: []Option{WithIdleTimeout(50 * time.Millisecond), WithBatchSize(1)},
+			call: func(ctx context.Context, cancel context.CancelFunc, client pb.PingPongClient) error {
+				stream, err := client.MultiPingPong(ctx)
+				if err != nil {
+					return err
+				}
+				if err := stream.Send(&pb.PingRequest{Value: "ping"}); err != nil {
+					return err
+				}
+				for {
+					if _, err := stream.Recv(); err != nil {
+						return err
+					}
+				}
+			},
+			clientCode: codes.DeadlineExceeded, serverCode: []codes.Code{codes.DeadlineExceeded},
+		},
+		{
+			name:    "multi ping pong deadline exceeded",
+			timeout: 50 * time.Millisecond,
+			call: func(ctx context.Context, cancel context.CancelFunc, client pb.PingPongClient) error {
+				stream, err := client.MultiPingPong(ctx)
+				if err != nil {
+					return err
+				}
+				_, err = stream.Recv()
+				return err
+			},
+			clientCode: codes.DeadlineExceeded, serverCode: []codes.Code{codes.DeadlineExceeded, codes.Canceled},
+		},
+		{
+			name: "multi ping pong half-closed",
+			opts: []Option{WithIdleTimeout(time.Second)},
+			call: func(ctx context.Context, cancel context.CancelFunc, client pb.PingPongClient) error {
+				// 客户端结束发送后服务端发送剩余响应并正常结束
+				stream, err := client.MultiPingPong(ctx)
+				if err != nil {
+					return err
+				}
+				for i := 0; i < 4; i++ {
+					if err := stream.Send(&pb.PingRequest{Value: "ping"}); err != nil {
+						return err
+					}
+				}
+				stream.CloseSend()
+				for {
+					if _, err := stream.Recv(); err == io.EOF {
+						return nil
+					} else if err != nil {
+						return err
+					}
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 测试结束、服务关闭后检查 goroutine 泄漏
+			ignore := goleak.IgnoreCurrent()
+			t.Cleanup(func() { goleak.VerifyNone(t, ignore) })
+
+			interceptor, errs := handlerErrors()
+			srv := pingtest.NewServer(t, NewPingPongServer(tt.opts...), pingtest.WithServerOptions(grpc.StreamInterceptor(interceptor)))
+
+			ctx, cancel := context.WithCancel(context.Background())
+			if tt.timeout > 0 {
+				ctx, cancel = context.WithTimeout(context.Background(), tt.timeout)
+			}
+			defer cancel()
+			if err := tt.call(ctx, cancel, srv.Client); status.Code(err) != tt.clientCode {
+				t.Errorf("client err = %v, want %s", err, tt.clientCode)
+			}
+
+			// 服务端方法应及时返回
+			select {
+			case err := <-errs:
+				want := tt.serverCode
+				if want == nil {
+					want = []codes.Code{codes.OK}
+				}
+				if !containsCode(want, status.Code(err)) {
+					t.Errorf("server err = %v, want %v", err, want)
+				}
+			case <-time.After(time.Second):
+				t.Fatal("server handler did not return")
+			}
+		})
+	}
+}
+
+func containsCode(want []codes.Code, c codes.Code) bool {
+	for _, code := range want {
+		if code == c {
+			return true
+		}
+	}
+	return false
+}
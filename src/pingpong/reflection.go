@@ -0,0 +1,79 @@
+package pingpong
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
+	v1reflectiongrpc "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	v1alphareflectiongrpc "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+)
+
+// ReflectionAuthFunc 反射服务的认证方法，返回错误时拒绝请求，错误需为 status 错误
+type ReflectionAuthFunc func(ctx context.Context) error
+
+// reflectionOptions 反射服务配置，默认开启
+type reflectionOptions struct {
+	disabled bool
+	auth     ReflectionAuthFunc
+}
+
+// WithoutReflection 关闭反射服务，用于生产环境
+func WithoutReflection() ServerOption {
+	return func(o *serverOptions) {
+		o.reflection.disabled = true
+	}
+}
+
+// WithReflectionAuth 开启反射服务，且请求需通过 auth 认证
+//
+// auth 在反射服务的处理方法中调用，不依赖拦截器，用于没有注册流认证拦截器，
+// 或反射服务需要与其他服务使用不同认证方法的场景。
+func WithReflectionAuth(auth ReflectionAuthFunc) ServerOption {
+	return func(o *serverOptions) {
+		o.reflection.disabled = false
+		o.reflection.auth = auth
+	}
+}
+
+// registerReflection 注册 v1 和 v1alpha 版本的反射服务，grpcurl 等工具无需 proto 文件即可调用
+func registerReflection(s *grpc.Server, o reflectionOptions) {
+	if o.disabled {
+		return
+	}
+	if o.auth == nil {
+		reflection.Register(s)
+		return
+	}
+
+	v1 := reflection.NewServerV1(reflection.ServerOptions{Services: s})
+	v1alpha := reflection.NewServer(reflection.ServerOptions{Services: s})
+	v1reflectiongrpc.RegisterServerReflectionServer(s, &authReflectionV1{ServerReflectionServer: v1, auth: o.auth})
+	v1alphareflectiongrpc.RegisterServerReflectionServer(s, &authReflectionV1Alpha{ServerReflectionServer: v1alpha, auth: o.auth})
+}
+
+// authReflectionV1 请求前先认证的 v1 反射服务
+type authReflectionV1 struct {
+	v1reflectiongrpc.ServerReflectionServer
+	auth ReflectionAuthFunc
+}
+
+func (s *authReflectionV1) ServerReflectionInfo(stream v1reflectiongrpc.ServerReflection_ServerReflectionInfoServer) error {
+	if err := s.auth(stream.Context()); err != nil {
+		return err
+	}
+	return s.ServerReflectionServer.ServerReflectionInfo(stream)
+}
+
+// authReflectionV1Alpha 请求前先认证的 v1alpha 反射服务
+type authReflectionV1Alpha struct {
+	v1alphareflectiongrpc.ServerReflectionServer
+	auth ReflectionAuthFunc
+}
+
+func (s *authReflectionV1Alpha) ServerReflectionInfo(stream v1alphareflectiongrpc.ServerReflection_ServerReflectionInfoServer) error {
+	if err := s.auth(stream.Context()); err != nil {
+		return err
+	}
+	return s.ServerReflectionServer.ServerReflectionInfo(stream)
+}
@@ -0,0 +1,117 @@
+package pingpong
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	v1reflectiongrpc "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	v1alphareflectiongrpc "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/grpc/status"
+
+	"github.com/jergoo/go-grpc-tutorial/pingtest"
+)
+
+// listServicesV1 通过 v1 反射服务获取服务列表
+func listServicesV1(ctx context.Context, conn *grpc.ClientConn) ([]string, error) {
+	stream, err := v1reflectiongrpc.NewServerReflectionClient(conn).ServerReflectionInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	err = stream.Send(&v1reflectiongrpc.ServerReflectionRequest{
+		MessageRequest: &v1reflectiongrpc.ServerReflectionRequest_ListServices{},
+	})
+	if err != nil {
+		return nil, err
+	}
+	res, err := stream.Recv()
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, s := range res.GetListServicesResponse().GetService() {
+		names = append(names, s.Name)
+	}
+	return names, nil
+}
+
+// listServicesV1Alpha 通过 v1alpha 反射服务获取服务列表
+func listServicesV1Alpha(ctx context.Context, conn *grpc.ClientConn) ([]string, error) {
+	stream, err := v1alphareflectiongrpc.NewServerReflectionClient(conn).ServerReflectionInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	err = stream.Send(&v1alphareflectiongrpc.ServerReflectionRequest{
+		MessageRequest: &v1alphareflectiongrpc.ServerReflectionRequest_ListServices{},
+	})
+	if err != nil {
+		return nil, err
+	}
+	res, err := stream.Recv()
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, s := range res.GetListServicesResponse().GetService() {
+		names = append(names, s.Name)
+	}
+	return names, nil
+}
+
+func TestReflection(t *testing.T) {
+	auth := func(ctx context.Context) error {
+		md, _ := metadata.FromIncomingContext(ctx)
+		if v := md.Get("authorization"); len(v) == 0 || v[0] != "token" {
+			return status.Error(codes.Unauthenticated, "token invalid")
+		}
+		return nil
+	}
+
+	tests := []struct {
+		name  string
+		opts  []ServerOption
+		token string
+		code  codes.Code
+	}{
+		{name: "default"},
+		{name: "disabled", opts: []ServerOption{WithoutReflection()}, code: codes.Unimplemented},
+		{name: "auth ok", opts: []ServerOption{WithReflectionAuth(auth)}, token: "token"},
+		{name: "auth missing", opts: []ServerOption{WithReflectionAuth(auth)}, code: codes.Unauthenticated},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			srv := NewServer(tt.opts...)
+			conn := pingtest.Start(t, srv.Server).Conn
+
+			ctx := context.Background()
+			if tt.token != "" {
+				ctx = metadata.AppendToOutgoingContext(ctx, "authorization", tt.token)
+			}
+			for version, list := range map[string]func(context.Context, *grpc.ClientConn) ([]string, error){
+				"v1":      listServicesV1,
+				"v1alpha": listServicesV1Alpha,
+			} {
+				names, err := list(ctx, conn)
+				if status.Code(err) != tt.code {
+					t.Fatalf("%s: code = %v, want %v", version, status.Code(err), tt.code)
+				}
+				if err != nil {
+					continue
+				}
+				found := false
+				for _, name := range names {
+					if name == ServiceName {
+						found = true
+					}
+				}
+				if !found {
+					t.Errorf("%s: %s not in %v", version, ServiceName, names)
+				}
+			}
+		})
+	}
+}
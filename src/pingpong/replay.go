@@ -0,0 +1,234 @@
+package pingpong
+
+import (
+	"container/list"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/jergoo/go-grpc-tutorial/protos/ping" // 引入编译生成的包
+	"github.com/jergoo/go-grpc-tutorial/rpcerr"
+)
+
+// 断点续传默认配置
+const (
+	DefaultReplaySize       = 100         // 每个 MultiPong 流可以从最近多少条消息继续
+	DefaultReplayTTL        = time.Minute // 流断开后保留的时间
+	DefaultReplayMaxStreams = 10000       // 最多保留的流数
+)
+
+// WithReplay 开启 MultiPong 断点续传，客户端可以从每个流最近 size 条消息中的位置继续，
+// 流断开后保留 ttl，超过后无法继续。默认关闭，响应不携带 resume_token
+func WithReplay(size int, ttl time.Duration) Option {
+	return func(s *PingPongServer) {
+		s.replay.size, s.replay.ttl = size, ttl
+	}
+}
+
+// WithReplayMaxStreams 设置断点续传最多保留的流数，默认 DefaultReplayMaxStreams，超出后淘汰最早创建的流
+func WithReplayMaxStreams(n int) Option {
+	return func(s *PingPongServer) {
+		s.replay.maxStreams = n
+	}
+}
+
+// SweepReplay 每隔 ttl 清理过期的断点续传状态，直到 ctx 取消，关闭断点续传时直接返回
+//
+// Server.Run 在服务运行期间调用，单独使用 PingPongServer 时需要自行调用。
+func (s *PingPongServer) SweepReplay(ctx context.Context) {
+	s.replay.run(ctx)
+}
+
+// replayBuffer 保存 MultiPong 流的配置和发送位置
+//
+// 客户端断开后携带最后收到的 resume token 重新调用，补发之后的部分再继续生成，客户端不会漏收或重复收到消息。
+// 消息由配置和 seq 确定，不保存已发送的消息，补发时重新生成，每个流占用的内存与消息数和负载大小无关。
+type replayBuffer struct {
+	size       int
+	ttl        time.Duration
+	maxStreams int
+
+	mu      sync.Mutex
+	streams map[string]*replayStream
+	order   *list.List // 按创建顺序排列的流，超出 maxStreams 时从头部淘汰
+}
+
+// replayStream 一个 MultiPong 流的状态，由 replayBuffer.mu 保护
+type replayStream struct {
+	id    string // 为空时不保留
+	cfg   streamConfig
+	value string
+
+	last     uint64        // 已生成的最大 seq
+	gen      uint64        // 每次调用接管流时递增，旧的调用随之停止发送
+	attached bool          // 是否有调用正在发送
+	expires  time.Time     // 没有调用发送时的过期时间
+	elem     *list.Element // 在 replayBuffer.order 中的位置
+}
+
+func newReplayBuffer() *replayBuffer {
+	return &replayBuffer{maxStreams: DefaultReplayMaxStreams, streams: map[string]*replayStream{}, order: list.New()}
+}
+
+// enabled 是否开启断点续传
+func (b *replayBuffer) enabled() bool {
+	return b.size > 0 && b.maxStreams > 0
+}
+
+// create 创建新的流，关闭断点续传时不保留
+func (b *replayBuffer) create(cfg streamConfig, value string) (*replayStream, uint64) {
+	rs := &replayStream{cfg: cfg, value: value, gen: 1, attached: true}
+	if !b.enabled() {
+		return rs, rs.gen
+	}
+	rs.id = newStreamID()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.streams) >= b.maxStreams {
+		b.sweep(time.Now())
+	}
+	for len(b.streams) >= b.maxStreams {
+		b.remove(b.order.Front().Value.(*replayStream))
+	}
+	rs.elem = b.order.PushBack(rs)
+	b.streams[rs.id] = rs
+	return rs, rs.gen
+}
+
+// resume 根据 resume token 接管流，返回接管后的 gen 和客户端已收到的 seq
+func (b *replayBuffer) resume(token string) (*replayStream, uint64, uint64, error) {
+	id, seq, ok := parseResumeToken(token)
+	if !ok {
+		return nil, 0, 0, rpcerr.New(codes.InvalidArgument, "invalid resume token").
+			WithReason(rpcerr.ReasonInvalidRequest, nil).
+			WithFieldViolation("resume_token", "must be a resume_token returned by MultiPong").
+			Err()
+	}
+	if !b.enabled() {
+		return nil, 0, 0, resumeUnavailable("resume disabled")
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	rs := b.streams[id]
+	if rs == nil || (!rs.attached && time.Now().After(rs.expires)) {
+		return nil, 0, 0, resumeUnavailable("stream expired or unknown")
+	}
+	if seq > rs.last {
+		return nil, 0, 0, resumeUnavailable("position not sent yet")
+	}
+	// 只能从最近 size 条消息中的位置继续
+	if rs.last-seq > uint64(b.size) {
+		return nil, 0, 0, resumeUnavailable("position no longer retained")
+	}
+	rs.gen++
+	rs.attached = true
+	return rs, rs.gen, seq, nil
+}
+
+// next 返回 seq 对应的消息，不包含负载，fresh 表示新生成，流结束时返回 nil
+//
+// 其他调用接管流后返回 codes.Aborted。
+func (b *replayBuffer) next(rs *replayStream, gen, seq uint64) (msg *pb.PongResponse, fresh bool, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if rs.gen != gen {
+		return nil, false, status.Error(codes.Aborted, "stream resumed by another call")
+	}
+	if seq > rs.last {
+		if seq > uint64(rs.cfg.replyCount) {
+			return nil, false, nil
+		}
+		rs.last, fresh = seq, true
+	}
+
+	msg = &pb.PongResponse{Value: rs.value, Seq: seq}
+	if rs.id != "" {
+		msg.ResumeToken = rs.id + "-" + strconv.FormatUint(seq, 10)
+	}
+	return msg, fresh, nil
+}
+
+// release 调用结束，流在 ttl 后过期
+func (b *replayBuffer) release(rs *replayStream, gen uint64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if rs.gen != gen || rs.id == "" {
+		return
+	}
+	rs.attached = false
+	rs.expires = time.Now().Add(b.ttl)
+	if b.ttl <= 0 {
+		b.remove(rs)
+	}
+}
+
+// run 每隔 ttl 删除过期的流，直到 ctx 取消
+func (b *replayBuffer) run(ctx context.Context) {
+	if !b.enabled() || b.ttl <= 0 {
+		return
+	}
+	ticker := time.NewTicker(b.ttl)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			b.mu.Lock()
+			b.sweep(now)
+			b.mu.Unlock()
+		}
+	}
+}
+
+// sweep 删除过期的流，调用时需要持有 b.mu
+func (b *replayBuffer) sweep(now time.Time) {
+	for _, rs := range b.streams {
+		if !rs.attached && now.After(rs.expires) {
+			b.remove(rs)
+		}
+	}
+}
+
+// remove 删除流，调用时需要持有 b.mu，正在发送的调用不受影响，但之后无法继续
+func (b *replayBuffer) remove(rs *replayStream) {
+	if b.streams[rs.id] != rs {
+		return
+	}
+	delete(b.streams, rs.id)
+	b.order.Remove(rs.elem)
+}
+
+// resumeUnavailable 无法从 resume token 继续时返回的错误，客户端需要重新开始
+func resumeUnavailable(msg string) error {
+	return rpcerr.New(codes.FailedPrecondition, msg).WithReason(rpcerr.ReasonResumeUnavailable, nil).Err()
+}
+
+// newStreamID 生成随机的流 ID
+func newStreamID() string {
+	b := make([]byte, 12)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// parseResumeToken 解析格式为 <流 ID>-<seq> 的 resume token
+func parseResumeToken(token string) (id string, seq uint64, ok bool) {
+	id, n, ok := strings.Cut(token, "-")
+	if !ok || id == "" {
+		return "", 0, false
+	}
+	seq, err := strconv.ParseUint(n, 10, 64)
+	if err != nil {
+		return "", 0, false
+	}
+	return id, seq, true
+}
@@ -0,0 +1,111 @@
+package pingpong
+
+import (
+	"context"
+	"net"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/jergoo/go-grpc-tutorial/lifecycle"
+	pb "github.com/jergoo/go-grpc-tutorial/protos/ping" // 引入编译生成的包
+)
+
+// ServerOption grpc Server 构建配置项
+type ServerOption func(*serverOptions)
+
+type serverOptions struct {
+	grpcOpts       []grpc.ServerOption
+	pingPongOpts   []Option
+	healthServices []string
+	healthChecks   []healthCheck
+	reflection     reflectionOptions
+}
+
+// WithGRPCOptions 添加 grpc.ServerOption，如拦截器、TLS 证书
+func WithGRPCOptions(opts ...grpc.ServerOption) ServerOption {
+	return func(o *serverOptions) {
+		o.grpcOpts = append(o.grpcOpts, opts...)
+	}
+}
+
+// WithPingPongOptions 添加 PingPongServer 配置项
+func WithPingPongOptions(opts ...Option) ServerOption {
+	return func(o *serverOptions) {
+		o.pingPongOpts = append(o.pingPongOpts, opts...)
+	}
+}
+
+// Server 已注册 PingPong 服务和健康检查服务的 grpc Server
+type Server struct {
+	*grpc.Server
+
+	PingPong *PingPongServer
+	Health   *health.Server     // grpc.health.v1.Health 服务，可通过 SetServingStatus 更新状态
+	Tracker  *lifecycle.Tracker // 统计进行中的 PingPong 请求，退出时报告被中断的请求数
+
+	streams *lifecycle.StreamCloser
+	checker *healthChecker
+}
+
+// NewServer 创建 grpc Server 并注册 PingPongServer、健康检查服务和反射服务
+func NewServer(opts ...ServerOption) *Server {
+	o := &serverOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	tracker := lifecycle.NewTracker(pb.PingPong_ServiceDesc.ServiceName)
+	// health Watch 和反射流退出时在排空前关闭，放在最外层使内层拦截器仍能处理 panic
+	streams := lifecycle.NewStreamCloser(
+		healthpb.Health_Watch_FullMethodName,
+		"/grpc.reflection.v1.ServerReflection/*",
+		"/grpc.reflection.v1alpha.ServerReflection/*",
+	)
+	grpcOpts := append([]grpc.ServerOption{
+		grpc.StatsHandler(tracker),
+		grpc.ChainStreamInterceptor(streams.StreamServerInterceptor()),
+	}, o.grpcOpts...)
+
+	s := &Server{
+		Server:   grpc.NewServer(grpcOpts...),
+		PingPong: NewPingPongServer(o.pingPongOpts...),
+		Health:   newHealthServer(o.healthServices),
+		Tracker:  tracker,
+		streams:  streams,
+	}
+	s.checker = newHealthChecker(s.Health, o.healthChecks)
+	// 注册 PingPongServer
+	pb.RegisterPingPongServer(s.Server, s.PingPong)
+	// 注册健康检查服务
+	healthpb.RegisterHealthServer(s.Server, s.Health)
+	// 注册反射服务
+	registerReflection(s.Server, o.reflection)
+	return s
+}
+
+// Run 在 lis 上提供服务，执行依赖检查并清理过期的断点续传状态，收到 SIGINT/SIGTERM 或 ctx 取消后优雅退出
+//
+// 退出时所有服务的健康状态置为 NOT_SERVING，并结束 health Watch 和反射流。
+func (s *Server) Run(ctx context.Context, lis net.Listener, opts ...lifecycle.Option) (lifecycle.Report, error) {
+	bgCtx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		s.checker.run(bgCtx)
+	}()
+	go func() {
+		defer wg.Done()
+		s.PingPong.SweepReplay(bgCtx)
+	}()
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+
+	opts = append([]lifecycle.Option{lifecycle.WithTracker(s.Tracker), lifecycle.WithHealth(s.Health), lifecycle.WithStreamCloser(s.streams)}, opts...)
+	return lifecycle.Serve(ctx, s.Server, lis, opts...)
+}
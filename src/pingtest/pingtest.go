@@ -0,0 +1,148 @@
+// Package pingtest 提供进程内的 PingPong 测试服务
+//
+// 基于 bufconn 在内存中监听，测试无需手动启动 server.go，每个测试独立启停，可并行执行。
+package pingtest
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+
+	pb "github.com/jergoo/go-grpc-tutorial/protos/ping" // 引入编译生成的包
+)
+
+// Target bufconn 连接使用的服务地址，仅用于标识，实际连接由 DialOption 完成
+//
+// 使用 passthrough 解析器，grpc.NewClient 默认的 dns 解析器无法解析该地址。
+const Target = "passthrough:///bufnet"
+
+// bufSize bufconn 缓冲区大小
+const bufSize = 1024 * 1024
+
+// Option 测试服务配置项
+type Option func(*options)
+
+type options struct {
+	serverOpts  []grpc.ServerOption
+	dialOpts    []grpc.DialOption
+	serverCreds credentials.TransportCredentials
+	clientCreds credentials.TransportCredentials
+}
+
+// WithServerOptions 添加服务端配置，如拦截器
+func WithServerOptions(opts ...grpc.ServerOption) Option {
+	return func(o *options) {
+		o.serverOpts = append(o.serverOpts, opts...)
+	}
+}
+
+// WithDialOptions 添加默认客户端连接配置，如客户端拦截器
+func WithDialOptions(opts ...grpc.DialOption) Option {
+	return func(o *options) {
+		o.dialOpts = append(o.dialOpts, opts...)
+	}
+}
+
+// WithTLS 开启 TLS，分别指定服务端和默认客户端的证书配置
+func WithTLS(server, client credentials.TransportCredentials) Option {
+	return func(o *options) {
+		o.serverCreds = server
+		o.clientCreds = client
+	}
+}
+
+// WithPerRPCCredentials 默认客户端每次请求携带的认证信息
+func WithPerRPCCredentials(creds credentials.PerRPCCredentials) Option {
+	return WithDialOptions(grpc.WithPerRPCCredentials(creds))
+}
+
+// Server 进程内运行的 PingPong 服务
+type Server struct {
+	Client pb.PingPongClient // 已连接的默认客户端
+	Conn   *grpc.ClientConn  // 默认客户端使用的连接
+
+	lis *bufconn.Listener
+	srv *grpc.Server
+}
+
+// NewServer 启动测试服务并建立默认客户端连接，测试结束时自动关闭
+func NewServer(t testing.TB, impl pb.PingPongServer, opts ...Option) *Server {
+	t.Helper()
+
+	o := newOptions(opts)
+	serverOpts := o.serverOpts
+	if o.serverCreds != nil {
+		serverOpts = append([]grpc.ServerOption{grpc.Creds(o.serverCreds)}, serverOpts...)
+	}
+
+	srv := grpc.NewServer(serverOpts...)
+	pb.RegisterPingPongServer(srv, impl)
+	return start(t, srv, o)
+}
+
+// Start 在 bufconn 上运行已构建好的 srv，如 pingpong.NewServer 的结果
+//
+// srv 的服务端配置已确定，WithServerOptions 和 WithTLS 的服务端证书不生效。
+func Start(t testing.TB, srv *grpc.Server, opts ...Option) *Server {
+	t.Helper()
+	return start(t, srv, newOptions(opts))
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+func start(t testing.TB, srv *grpc.Server, o *options) *Server {
+	t.Helper()
+
+	s := &Server{
+		lis: bufconn.Listen(bufSize),
+		srv: srv,
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.srv.Serve(s.lis)
+	}()
+	t.Cleanup(func() {
+		s.srv.Stop()
+		<-done
+	})
+
+	creds := o.clientCreds
+	if creds == nil {
+		creds = insecure.NewCredentials()
+	}
+	s.Conn = s.Dial(t, append([]grpc.DialOption{grpc.WithTransportCredentials(creds)}, o.dialOpts...)...)
+	s.Client = pb.NewPingPongClient(s.Conn)
+	return s
+}
+
+// DialOption 通过 bufconn 建立连接的配置，可直接传给 grpc.NewClient
+func (s *Server) DialOption() grpc.DialOption {
+	return grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+		return s.lis.DialContext(ctx)
+	})
+}
+
+// Dial 使用自定义配置建立新连接，opts 需包含传输层认证配置，测试结束时自动关闭
+func (s *Server) Dial(t testing.TB, opts ...grpc.DialOption) *grpc.ClientConn {
+	t.Helper()
+
+	conn, err := grpc.NewClient(Target, append([]grpc.DialOption{s.DialOption()}, opts...)...)
+	if err != nil {
+		t.Fatalf("dial bufconn: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
@@ -0,0 +1,57 @@
+package pingtest
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	pb "github.com/jergoo/go-grpc-tutorial/protos/ping" // 引入编译生成的包
+)
+
+type pongServer struct {
+	pb.UnimplementedPingPongServer
+}
+
+func (s *pongServer) Ping(ctx context.Context, req *pb.PingRequest) (*pb.PongResponse, error) {
+	return &pb.PongResponse{Value: "pong"}, nil
+}
+
+func TestNewServer(t *testing.T) {
+	serverCreds, err := credentials.NewServerTLSFromFile("../auth/keys/server.crt", "../auth/keys/server.key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientCreds, err := credentials.NewClientTLSFromFile("../auth/keys/server.crt", "grpc.server")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		opts []Option
+	}{
+		{name: "insecure"},
+		{name: "tls", opts: []Option{WithTLS(serverCreds, clientCreds)}},
+		{name: "interceptor", opts: []Option{
+			WithServerOptions(grpc.UnaryInterceptor(func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+				return handler(ctx, req)
+			})),
+		}},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			srv := NewServer(t, &pongServer{}, tt.opts...)
+			res, err := srv.Client.Ping(context.Background(), &pb.PingRequest{Value: "ping"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.Value != "pong" {
+				t.Errorf("got %q, want %q", res.Value, "pong")
+			}
+		})
+	}
+}
@@ -7,8 +7,11 @@
 package ping
 
 import (
+	_ "github.com/jergoo/go-grpc-tutorial/protos/validate"
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
 	reflect "reflect"
 	sync "sync"
 )
@@ -20,13 +23,115 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+// ReplyMode 响应内容
+type StreamOptions_ReplyMode int32
+
+const (
+	StreamOptions_REPLY_MODE_PONG StreamOptions_ReplyMode = 0 // 固定返回 pong
+	StreamOptions_REPLY_MODE_ECHO StreamOptions_ReplyMode = 1 // 返回收到的请求内容
+)
+
+// Enum value maps for StreamOptions_ReplyMode.
+var (
+	StreamOptions_ReplyMode_name = map[int32]string{
+		0: "REPLY_MODE_PONG",
+		1: "REPLY_MODE_ECHO",
+	}
+	StreamOptions_ReplyMode_value = map[string]int32{
+		"REPLY_MODE_PONG": 0,
+		"REPLY_MODE_ECHO": 1,
+	}
+)
+
+func (x StreamOptions_ReplyMode) Enum() *StreamOptions_ReplyMode {
+	p := new(StreamOptions_ReplyMode)
+	*p = x
+	return p
+}
+
+func (x StreamOptions_ReplyMode) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (StreamOptions_ReplyMode) Descriptor() protoreflect.EnumDescriptor {
+	return file_protos_ping_ping_proto_enumTypes[0].Descriptor()
+}
+
+func (StreamOptions_ReplyMode) Type() protoreflect.EnumType {
+	return &file_protos_ping_ping_proto_enumTypes[0]
+}
+
+func (x StreamOptions_ReplyMode) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use StreamOptions_ReplyMode.Descriptor instead.
+func (StreamOptions_ReplyMode) EnumDescriptor() ([]byte, []int) {
+	return file_protos_ping_ping_proto_rawDescGZIP(), []int{1, 0}
+}
+
+// Termination 收到的消息超过 max_messages 时的处理方式
+type StreamOptions_Termination int32
+
+const (
+	StreamOptions_TERMINATION_ERROR StreamOptions_Termination = 0 // 返回 codes.ResourceExhausted
+	StreamOptions_TERMINATION_CLOSE StreamOptions_Termination = 1 // 正常结束流，只处理前 max_messages 条消息
+)
+
+// Enum value maps for StreamOptions_Termination.
+var (
+	StreamOptions_Termination_name = map[int32]string{
+		0: "TERMINATION_ERROR",
+		1: "TERMINATION_CLOSE",
+	}
+	StreamOptions_Termination_value = map[string]int32{
+		"TERMINATION_ERROR": 0,
+		"TERMINATION_CLOSE": 1,
+	}
+)
+
+func (x StreamOptions_Termination) Enum() *StreamOptions_Termination {
+	p := new(StreamOptions_Termination)
+	*p = x
+	return p
+}
+
+func (x StreamOptions_Termination) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (StreamOptions_Termination) Descriptor() protoreflect.EnumDescriptor {
+	return file_protos_ping_ping_proto_enumTypes[1].Descriptor()
+}
+
+func (StreamOptions_Termination) Type() protoreflect.EnumType {
+	return &file_protos_ping_ping_proto_enumTypes[1]
+}
+
+func (x StreamOptions_Termination) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use StreamOptions_Termination.Descriptor instead.
+func (StreamOptions_Termination) EnumDescriptor() ([]byte, []int) {
+	return file_protos_ping_ping_proto_rawDescGZIP(), []int{1, 1}
+}
+
 // PingRequest 请求结构
 type PingRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Value string `protobuf:"bytes,1,opt,name=value,proto3" json:"value,omitempty"`
+	Value  string                 `protobuf:"bytes,1,opt,name=value,proto3" json:"value,omitempty"`
+	Seq    uint64                 `protobuf:"varint,2,opt,name=seq,proto3" json:"seq,omitempty"`                    // 客户端生成的序号，服务端原样返回
+	SentAt *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=sent_at,json=sentAt,proto3" json:"sent_at,omitempty"` // 客户端发送时间
+	// 请求负载，用于测试客户端到服务端方向的吞吐
+	Payload []byte `protobuf:"bytes,4,opt,name=payload,proto3" json:"payload,omitempty"`
+	// 流的行为配置，客户端流只读取第一条消息中的配置
+	Options *StreamOptions `protobuf:"bytes,5,opt,name=options,proto3" json:"options,omitempty"`
+	// MultiPong 断点续传，值为最后收到的响应的 resume_token，服务端从该响应之后继续发送，忽略其他字段
+	ResumeToken string `protobuf:"bytes,6,opt,name=resume_token,json=resumeToken,proto3" json:"resume_token,omitempty"`
 }
 
 func (x *PingRequest) Reset() {
@@ -68,19 +173,157 @@ func (x *PingRequest) GetValue() string {
 	return ""
 }
 
+func (x *PingRequest) GetSeq() uint64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *PingRequest) GetSentAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.SentAt
+	}
+	return nil
+}
+
+func (x *PingRequest) GetPayload() []byte {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+func (x *PingRequest) GetOptions() *StreamOptions {
+	if x != nil {
+		return x.Options
+	}
+	return nil
+}
+
+func (x *PingRequest) GetResumeToken() string {
+	if x != nil {
+		return x.ResumeToken
+	}
+	return ""
+}
+
+// StreamOptions 流的行为配置，未设置的字段使用服务端默认值
+type StreamOptions struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ReplyCount    uint32                    `protobuf:"varint,1,opt,name=reply_count,json=replyCount,proto3" json:"reply_count,omitempty"`         // MultiPong 响应消息数
+	ReplyInterval *durationpb.Duration      `protobuf:"bytes,2,opt,name=reply_interval,json=replyInterval,proto3" json:"reply_interval,omitempty"` // MultiPong 响应发送间隔
+	PayloadSize   uint32                    `protobuf:"varint,3,opt,name=payload_size,json=payloadSize,proto3" json:"payload_size,omitempty"`      // MultiPong 每条响应的负载字节数
+	BatchSize     uint32                    `protobuf:"varint,4,opt,name=batch_size,json=batchSize,proto3" json:"batch_size,omitempty"`            // MultiPingPong 每收到多少条消息响应一次
+	ReplyMode     StreamOptions_ReplyMode   `protobuf:"varint,5,opt,name=reply_mode,json=replyMode,proto3,enum=protos.StreamOptions_ReplyMode" json:"reply_mode,omitempty"`
+	MaxMessages   uint32                    `protobuf:"varint,6,opt,name=max_messages,json=maxMessages,proto3" json:"max_messages,omitempty"` // MultiPing、MultiPingPong 最多接收的消息数
+	Termination   StreamOptions_Termination `protobuf:"varint,7,opt,name=termination,proto3,enum=protos.StreamOptions_Termination" json:"termination,omitempty"`
+}
+
+func (x *StreamOptions) Reset() {
+	*x = StreamOptions{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_protos_ping_ping_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *StreamOptions) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamOptions) ProtoMessage() {}
+
+func (x *StreamOptions) ProtoReflect() protoreflect.Message {
+	mi := &file_protos_ping_ping_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamOptions.ProtoReflect.Descriptor instead.
+func (*StreamOptions) Descriptor() ([]byte, []int) {
+	return file_protos_ping_ping_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *StreamOptions) GetReplyCount() uint32 {
+	if x != nil {
+		return x.ReplyCount
+	}
+	return 0
+}
+
+func (x *StreamOptions) GetReplyInterval() *durationpb.Duration {
+	if x != nil {
+		return x.ReplyInterval
+	}
+	return nil
+}
+
+func (x *StreamOptions) GetPayloadSize() uint32 {
+	if x != nil {
+		return x.PayloadSize
+	}
+	return 0
+}
+
+func (x *StreamOptions) GetBatchSize() uint32 {
+	if x != nil {
+		return x.BatchSize
+	}
+	return 0
+}
+
+func (x *StreamOptions) GetReplyMode() StreamOptions_ReplyMode {
+	if x != nil {
+		return x.ReplyMode
+	}
+	return StreamOptions_REPLY_MODE_PONG
+}
+
+func (x *StreamOptions) GetMaxMessages() uint32 {
+	if x != nil {
+		return x.MaxMessages
+	}
+	return 0
+}
+
+func (x *StreamOptions) GetTermination() StreamOptions_Termination {
+	if x != nil {
+		return x.Termination
+	}
+	return StreamOptions_TERMINATION_ERROR
+}
+
 // PongResponse 响应结构
 type PongResponse struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Value string `protobuf:"bytes,1,opt,name=value,proto3" json:"value,omitempty"`
+	Value         string                 `protobuf:"bytes,1,opt,name=value,proto3" json:"value,omitempty"`
+	Seq           uint64                 `protobuf:"varint,2,opt,name=seq,proto3" json:"seq,omitempty"`                                          // 对应请求的序号
+	ReceivedAt    *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=received_at,json=receivedAt,proto3" json:"received_at,omitempty"`           // 服务端收到请求的时间
+	SentAt        *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=sent_at,json=sentAt,proto3" json:"sent_at,omitempty"`                       // 服务端发送响应的时间
+	Payload       []byte                 `protobuf:"bytes,5,opt,name=payload,proto3" json:"payload,omitempty"`                                   // 响应负载，大小由请求的 payload_size 指定
+	Received      uint64                 `protobuf:"varint,6,opt,name=received,proto3" json:"received,omitempty"`                                // MultiPing 收到的消息数
+	ReceivedBytes uint64                 `protobuf:"varint,7,opt,name=received_bytes,json=receivedBytes,proto3" json:"received_bytes,omitempty"` // MultiPing 收到的负载字节数
+	ResumeToken   string                 `protobuf:"bytes,8,opt,name=resume_token,json=resumeToken,proto3" json:"resume_token,omitempty"`        // MultiPong 当前位置，流中断后用于继续接收，服务端关闭断点续传时为空
 }
 
 func (x *PongResponse) Reset() {
 	*x = PongResponse{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_protos_ping_ping_proto_msgTypes[1]
+		mi := &file_protos_ping_ping_proto_msgTypes[2]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -93,7 +336,7 @@ func (x *PongResponse) String() string {
 func (*PongResponse) ProtoMessage() {}
 
 func (x *PongResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_protos_ping_ping_proto_msgTypes[1]
+	mi := &file_protos_ping_ping_proto_msgTypes[2]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -106,7 +349,7 @@ func (x *PongResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PongResponse.ProtoReflect.Descriptor instead.
 func (*PongResponse) Descriptor() ([]byte, []int) {
-	return file_protos_ping_ping_proto_rawDescGZIP(), []int{1}
+	return file_protos_ping_ping_proto_rawDescGZIP(), []int{2}
 }
 
 func (x *PongResponse) GetValue() string {
@@ -116,33 +359,152 @@ func (x *PongResponse) GetValue() string {
 	return ""
 }
 
+func (x *PongResponse) GetSeq() uint64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *PongResponse) GetReceivedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ReceivedAt
+	}
+	return nil
+}
+
+func (x *PongResponse) GetSentAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.SentAt
+	}
+	return nil
+}
+
+func (x *PongResponse) GetPayload() []byte {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+func (x *PongResponse) GetReceived() uint64 {
+	if x != nil {
+		return x.Received
+	}
+	return 0
+}
+
+func (x *PongResponse) GetReceivedBytes() uint64 {
+	if x != nil {
+		return x.ReceivedBytes
+	}
+	return 0
+}
+
+func (x *PongResponse) GetResumeToken() string {
+	if x != nil {
+		return x.ResumeToken
+	}
+	return ""
+}
+
 var File_protos_ping_ping_proto protoreflect.FileDescriptor
 
 var file_protos_ping_ping_proto_rawDesc = []byte{
 	0x0a, 0x16, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73, 0x2f, 0x70, 0x69, 0x6e, 0x67, 0x2f, 0x70, 0x69,
 	0x6e, 0x67, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73,
-	0x22, 0x23, 0x0a, 0x0b, 0x50, 0x69, 0x6e, 0x67, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12,
-	0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05,
-	0x76, 0x61, 0x6c, 0x75, 0x65, 0x22, 0x24, 0x0a, 0x0c, 0x50, 0x6f, 0x6e, 0x67, 0x52, 0x65, 0x73,
+	0x1a, 0x1e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75,
+	0x66, 0x2f, 0x64, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x1a, 0x1f, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75,
+	0x66, 0x2f, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x1a, 0x1e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73, 0x2f, 0x76, 0x61, 0x6c, 0x69, 0x64, 0x61,
+	0x74, 0x65, 0x2f, 0x76, 0x61, 0x6c, 0x69, 0x64, 0x61, 0x74, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x22, 0xf5, 0x01, 0x0a, 0x0b, 0x50, 0x69, 0x6e, 0x67, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x12, 0x1e, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x42, 0x08, 0xc2, 0xf3, 0x18, 0x04, 0x08, 0x01, 0x18, 0x40, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75,
+	0x65, 0x12, 0x10, 0x0a, 0x03, 0x73, 0x65, 0x71, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x03,
+	0x73, 0x65, 0x71, 0x12, 0x33, 0x0a, 0x07, 0x73, 0x65, 0x6e, 0x74, 0x5f, 0x61, 0x74, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70,
+	0x52, 0x06, 0x73, 0x65, 0x6e, 0x74, 0x41, 0x74, 0x12, 0x22, 0x0a, 0x07, 0x70, 0x61, 0x79, 0x6c,
+	0x6f, 0x61, 0x64, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0c, 0x42, 0x08, 0xc2, 0xf3, 0x18, 0x04, 0x18,
+	0x80, 0x80, 0x40, 0x52, 0x07, 0x70, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x12, 0x2f, 0x0a, 0x07,
+	0x6f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x15, 0x2e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73, 0x2e, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x4f, 0x70, 0x74,
+	0x69, 0x6f, 0x6e, 0x73, 0x52, 0x07, 0x6f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x2a, 0x0a,
+	0x0c, 0x72, 0x65, 0x73, 0x75, 0x6d, 0x65, 0x5f, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x18, 0x06, 0x20,
+	0x01, 0x28, 0x09, 0x42, 0x07, 0xc2, 0xf3, 0x18, 0x03, 0x18, 0x80, 0x01, 0x52, 0x0b, 0x72, 0x65,
+	0x73, 0x75, 0x6d, 0x65, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x22, 0xa8, 0x04, 0x0a, 0x0d, 0x53, 0x74,
+	0x72, 0x65, 0x61, 0x6d, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x2e, 0x0a, 0x0b, 0x72,
+	0x65, 0x70, 0x6c, 0x79, 0x5f, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d,
+	0x42, 0x0d, 0xc2, 0xf3, 0x18, 0x09, 0x31, 0x00, 0x00, 0x00, 0x00, 0x80, 0x84, 0x2e, 0x41, 0x52,
+	0x0a, 0x72, 0x65, 0x70, 0x6c, 0x79, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x4c, 0x0a, 0x0e, 0x72,
+	0x65, 0x70, 0x6c, 0x79, 0x5f, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x76, 0x61, 0x6c, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x44, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x42, 0x0a,
+	0xc2, 0xf3, 0x18, 0x06, 0x42, 0x00, 0x4a, 0x02, 0x08, 0x3c, 0x52, 0x0d, 0x72, 0x65, 0x70, 0x6c,
+	0x79, 0x49, 0x6e, 0x74, 0x65, 0x72, 0x76, 0x61, 0x6c, 0x12, 0x30, 0x0a, 0x0c, 0x70, 0x61, 0x79,
+	0x6c, 0x6f, 0x61, 0x64, 0x5f, 0x73, 0x69, 0x7a, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0d, 0x42,
+	0x0d, 0xc2, 0xf3, 0x18, 0x09, 0x31, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x30, 0x41, 0x52, 0x0b,
+	0x70, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x53, 0x69, 0x7a, 0x65, 0x12, 0x2c, 0x0a, 0x0a, 0x62,
+	0x61, 0x74, 0x63, 0x68, 0x5f, 0x73, 0x69, 0x7a, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0d, 0x42,
+	0x0d, 0xc2, 0xf3, 0x18, 0x09, 0x31, 0x00, 0x00, 0x00, 0x00, 0x00, 0x40, 0x8f, 0x40, 0x52, 0x09,
+	0x62, 0x61, 0x74, 0x63, 0x68, 0x53, 0x69, 0x7a, 0x65, 0x12, 0x46, 0x0a, 0x0a, 0x72, 0x65, 0x70,
+	0x6c, 0x79, 0x5f, 0x6d, 0x6f, 0x64, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x1f, 0x2e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73, 0x2e, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x4f, 0x70, 0x74,
+	0x69, 0x6f, 0x6e, 0x73, 0x2e, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x4d, 0x6f, 0x64, 0x65, 0x42, 0x06,
+	0xc2, 0xf3, 0x18, 0x02, 0x38, 0x01, 0x52, 0x09, 0x72, 0x65, 0x70, 0x6c, 0x79, 0x4d, 0x6f, 0x64,
+	0x65, 0x12, 0x30, 0x0a, 0x0c, 0x6d, 0x61, 0x78, 0x5f, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65,
+	0x73, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0d, 0x42, 0x0d, 0xc2, 0xf3, 0x18, 0x09, 0x31, 0x00, 0x00,
+	0x00, 0x00, 0x80, 0x84, 0x2e, 0x41, 0x52, 0x0b, 0x6d, 0x61, 0x78, 0x4d, 0x65, 0x73, 0x73, 0x61,
+	0x67, 0x65, 0x73, 0x12, 0x4b, 0x0a, 0x0b, 0x74, 0x65, 0x72, 0x6d, 0x69, 0x6e, 0x61, 0x74, 0x69,
+	0x6f, 0x6e, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x21, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x73, 0x2e, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x2e,
+	0x54, 0x65, 0x72, 0x6d, 0x69, 0x6e, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x42, 0x06, 0xc2, 0xf3, 0x18,
+	0x02, 0x38, 0x01, 0x52, 0x0b, 0x74, 0x65, 0x72, 0x6d, 0x69, 0x6e, 0x61, 0x74, 0x69, 0x6f, 0x6e,
+	0x22, 0x35, 0x0a, 0x09, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x4d, 0x6f, 0x64, 0x65, 0x12, 0x13, 0x0a,
+	0x0f, 0x52, 0x45, 0x50, 0x4c, 0x59, 0x5f, 0x4d, 0x4f, 0x44, 0x45, 0x5f, 0x50, 0x4f, 0x4e, 0x47,
+	0x10, 0x00, 0x12, 0x13, 0x0a, 0x0f, 0x52, 0x45, 0x50, 0x4c, 0x59, 0x5f, 0x4d, 0x4f, 0x44, 0x45,
+	0x5f, 0x45, 0x43, 0x48, 0x4f, 0x10, 0x01, 0x22, 0x3b, 0x0a, 0x0b, 0x54, 0x65, 0x72, 0x6d, 0x69,
+	0x6e, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x15, 0x0a, 0x11, 0x54, 0x45, 0x52, 0x4d, 0x49, 0x4e,
+	0x41, 0x54, 0x49, 0x4f, 0x4e, 0x5f, 0x45, 0x52, 0x52, 0x4f, 0x52, 0x10, 0x00, 0x12, 0x15, 0x0a,
+	0x11, 0x54, 0x45, 0x52, 0x4d, 0x49, 0x4e, 0x41, 0x54, 0x49, 0x4f, 0x4e, 0x5f, 0x43, 0x4c, 0x4f,
+	0x53, 0x45, 0x10, 0x01, 0x22, 0xa8, 0x02, 0x0a, 0x0c, 0x50, 0x6f, 0x6e, 0x67, 0x52, 0x65, 0x73,
 	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x01,
-	0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x32, 0xf1, 0x01, 0x0a, 0x08,
-	0x50, 0x69, 0x6e, 0x67, 0x50, 0x6f, 0x6e, 0x67, 0x12, 0x31, 0x0a, 0x04, 0x50, 0x69, 0x6e, 0x67,
-	0x12, 0x13, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73, 0x2e, 0x50, 0x69, 0x6e, 0x67, 0x52, 0x65,
-	0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x14, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73, 0x2e, 0x50,
-	0x6f, 0x6e, 0x67, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x38, 0x0a, 0x09, 0x4d,
-	0x75, 0x6c, 0x74, 0x69, 0x50, 0x6f, 0x6e, 0x67, 0x12, 0x13, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
-	0x73, 0x2e, 0x50, 0x69, 0x6e, 0x67, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x14, 0x2e,
-	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73, 0x2e, 0x50, 0x6f, 0x6e, 0x67, 0x52, 0x65, 0x73, 0x70, 0x6f,
-	0x6e, 0x73, 0x65, 0x30, 0x01, 0x12, 0x38, 0x0a, 0x09, 0x4d, 0x75, 0x6c, 0x74, 0x69, 0x50, 0x69,
-	0x6e, 0x67, 0x12, 0x13, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73, 0x2e, 0x50, 0x69, 0x6e, 0x67,
-	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x14, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73,
-	0x2e, 0x50, 0x6f, 0x6e, 0x67, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x28, 0x01, 0x12,
-	0x3e, 0x0a, 0x0d, 0x4d, 0x75, 0x6c, 0x74, 0x69, 0x50, 0x69, 0x6e, 0x67, 0x50, 0x6f, 0x6e, 0x67,
-	0x12, 0x13, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73, 0x2e, 0x50, 0x69, 0x6e, 0x67, 0x52, 0x65,
-	0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x14, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73, 0x2e, 0x50,
-	0x6f, 0x6e, 0x67, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x28, 0x01, 0x30, 0x01, 0x42,
-	0x0d, 0x5a, 0x0b, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73, 0x2f, 0x70, 0x69, 0x6e, 0x67, 0x62, 0x06,
-	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x12, 0x10, 0x0a, 0x03, 0x73,
+	0x65, 0x71, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x03, 0x73, 0x65, 0x71, 0x12, 0x3b, 0x0a,
+	0x0b, 0x72, 0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x64, 0x5f, 0x61, 0x74, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x0a,
+	0x72, 0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x64, 0x41, 0x74, 0x12, 0x33, 0x0a, 0x07, 0x73, 0x65,
+	0x6e, 0x74, 0x5f, 0x61, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f,
+	0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69,
+	0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x06, 0x73, 0x65, 0x6e, 0x74, 0x41, 0x74, 0x12,
+	0x18, 0x0a, 0x07, 0x70, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0c,
+	0x52, 0x07, 0x70, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x12, 0x1a, 0x0a, 0x08, 0x72, 0x65, 0x63,
+	0x65, 0x69, 0x76, 0x65, 0x64, 0x18, 0x06, 0x20, 0x01, 0x28, 0x04, 0x52, 0x08, 0x72, 0x65, 0x63,
+	0x65, 0x69, 0x76, 0x65, 0x64, 0x12, 0x25, 0x0a, 0x0e, 0x72, 0x65, 0x63, 0x65, 0x69, 0x76, 0x65,
+	0x64, 0x5f, 0x62, 0x79, 0x74, 0x65, 0x73, 0x18, 0x07, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0d, 0x72,
+	0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x64, 0x42, 0x79, 0x74, 0x65, 0x73, 0x12, 0x21, 0x0a, 0x0c,
+	0x72, 0x65, 0x73, 0x75, 0x6d, 0x65, 0x5f, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x18, 0x08, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x0b, 0x72, 0x65, 0x73, 0x75, 0x6d, 0x65, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x32,
+	0xf1, 0x01, 0x0a, 0x08, 0x50, 0x69, 0x6e, 0x67, 0x50, 0x6f, 0x6e, 0x67, 0x12, 0x31, 0x0a, 0x04,
+	0x50, 0x69, 0x6e, 0x67, 0x12, 0x13, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73, 0x2e, 0x50, 0x69,
+	0x6e, 0x67, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x14, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x73, 0x2e, 0x50, 0x6f, 0x6e, 0x67, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12,
+	0x38, 0x0a, 0x09, 0x4d, 0x75, 0x6c, 0x74, 0x69, 0x50, 0x6f, 0x6e, 0x67, 0x12, 0x13, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x73, 0x2e, 0x50, 0x69, 0x6e, 0x67, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x1a, 0x14, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73, 0x2e, 0x50, 0x6f, 0x6e, 0x67, 0x52,
+	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x30, 0x01, 0x12, 0x38, 0x0a, 0x09, 0x4d, 0x75, 0x6c,
+	0x74, 0x69, 0x50, 0x69, 0x6e, 0x67, 0x12, 0x13, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73, 0x2e,
+	0x50, 0x69, 0x6e, 0x67, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x14, 0x2e, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x73, 0x2e, 0x50, 0x6f, 0x6e, 0x67, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
+	0x65, 0x28, 0x01, 0x12, 0x3e, 0x0a, 0x0d, 0x4d, 0x75, 0x6c, 0x74, 0x69, 0x50, 0x69, 0x6e, 0x67,
+	0x50, 0x6f, 0x6e, 0x67, 0x12, 0x13, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73, 0x2e, 0x50, 0x69,
+	0x6e, 0x67, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x14, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x73, 0x2e, 0x50, 0x6f, 0x6e, 0x67, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x28,
+	0x01, 0x30, 0x01, 0x42, 0x0d, 0x5a, 0x0b, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73, 0x2f, 0x70, 0x69,
+	0x6e, 0x67, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -157,25 +519,38 @@ func file_protos_ping_ping_proto_rawDescGZIP() []byte {
 	return file_protos_ping_ping_proto_rawDescData
 }
 
-var file_protos_ping_ping_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_protos_ping_ping_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_protos_ping_ping_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
 var file_protos_ping_ping_proto_goTypes = []interface{}{
-	(*PingRequest)(nil),  // 0: protos.PingRequest
-	(*PongResponse)(nil), // 1: protos.PongResponse
+	(StreamOptions_ReplyMode)(0),   // 0: protos.StreamOptions.ReplyMode
+	(StreamOptions_Termination)(0), // 1: protos.StreamOptions.Termination
+	(*PingRequest)(nil),            // 2: protos.PingRequest
+	(*StreamOptions)(nil),          // 3: protos.StreamOptions
+	(*PongResponse)(nil),           // 4: protos.PongResponse
+	(*timestamppb.Timestamp)(nil),  // 5: google.protobuf.Timestamp
+	(*durationpb.Duration)(nil),    // 6: google.protobuf.Duration
 }
 var file_protos_ping_ping_proto_depIdxs = []int32{
-	0, // 0: protos.PingPong.Ping:input_type -> protos.PingRequest
-	0, // 1: protos.PingPong.MultiPong:input_type -> protos.PingRequest
-	0, // 2: protos.PingPong.MultiPing:input_type -> protos.PingRequest
-	0, // 3: protos.PingPong.MultiPingPong:input_type -> protos.PingRequest
-	1, // 4: protos.PingPong.Ping:output_type -> protos.PongResponse
-	1, // 5: protos.PingPong.MultiPong:output_type -> protos.PongResponse
-	1, // 6: protos.PingPong.MultiPing:output_type -> protos.PongResponse
-	1, // 7: protos.PingPong.MultiPingPong:output_type -> protos.PongResponse
-	4, // [4:8] is the sub-list for method output_type
-	0, // [0:4] is the sub-list for method input_type
-	0, // [0:0] is the sub-list for extension type_name
-	0, // [0:0] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+	5,  // 0: protos.PingRequest.sent_at:type_name -> google.protobuf.Timestamp
+	3,  // 1: protos.PingRequest.options:type_name -> protos.StreamOptions
+	6,  // 2: protos.StreamOptions.reply_interval:type_name -> google.protobuf.Duration
+	0,  // 3: protos.StreamOptions.reply_mode:type_name -> protos.StreamOptions.ReplyMode
+	1,  // 4: protos.StreamOptions.termination:type_name -> protos.StreamOptions.Termination
+	5,  // 5: protos.PongResponse.received_at:type_name -> google.protobuf.Timestamp
+	5,  // 6: protos.PongResponse.sent_at:type_name -> google.protobuf.Timestamp
+	2,  // 7: protos.PingPong.Ping:input_type -> protos.PingRequest
+	2,  // 8: protos.PingPong.MultiPong:input_type -> protos.PingRequest
+	2,  // 9: protos.PingPong.MultiPing:input_type -> protos.PingRequest
+	2,  // 10: protos.PingPong.MultiPingPong:input_type -> protos.PingRequest
+	4,  // 11: protos.PingPong.Ping:output_type -> protos.PongResponse
+	4,  // 12: protos.PingPong.MultiPong:output_type -> protos.PongResponse
+	4,  // 13: protos.PingPong.MultiPing:output_type -> protos.PongResponse
+	4,  // 14: protos.PingPong.MultiPingPong:output_type -> protos.PongResponse
+	11, // [11:15] is the sub-list for method output_type
+	7,  // [7:11] is the sub-list for method input_type
+	7,  // [7:7] is the sub-list for extension type_name
+	7,  // [7:7] is the sub-list for extension extendee
+	0,  // [0:7] is the sub-list for field type_name
 }
 
 func init() { file_protos_ping_ping_proto_init() }
@@ -197,6 +572,18 @@ func file_protos_ping_ping_proto_init() {
 			}
 		}
 		file_protos_ping_ping_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*StreamOptions); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_protos_ping_ping_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*PongResponse); i {
 			case 0:
 				return &v.state
@@ -214,13 +601,14 @@ func file_protos_ping_ping_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_protos_ping_ping_proto_rawDesc,
-			NumEnums:      0,
-			NumMessages:   2,
+			NumEnums:      2,
+			NumMessages:   3,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
 		GoTypes:           file_protos_ping_ping_proto_goTypes,
 		DependencyIndexes: file_protos_ping_ping_proto_depIdxs,
+		EnumInfos:         file_protos_ping_ping_proto_enumTypes,
 		MessageInfos:      file_protos_ping_ping_proto_msgTypes,
 	}.Build()
 	File_protos_ping_ping_proto = out.File
@@ -0,0 +1,317 @@
+// Package ratelimit 基于令牌桶的服务端限流
+//
+// 令牌桶按调用方和 FullMethod 区分，调用方默认为对端 IP，也可以使用 token 的 subject 或指定的 metadata。
+// 单次调用和打开流使用一个令牌桶，流调用中每条接收的消息使用另一个令牌桶，
+// 超过限制时返回 codes.ResourceExhausted，错误详情包含 RetryInfo，同时通过 trailer 的 retry-after 返回需要等待的秒数。
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"path"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+
+	"github.com/jergoo/go-grpc-tutorial/jwtauth"
+	"github.com/jergoo/go-grpc-tutorial/middleware"
+	"github.com/jergoo/go-grpc-tutorial/rpcerr"
+)
+
+// RetryAfterKey 超过限制时 trailer 中返回的等待秒数
+const RetryAfterKey = "retry-after"
+
+// sweepInterval 清理空闲令牌桶的间隔
+const sweepInterval = time.Minute
+
+// Limit 令牌桶配置，Rate 为每秒生成的令牌数，Burst 为桶容量，Rate 为 0 时不限制
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// PerSecond 每秒 n 次，桶容量为 burst
+func PerSecond(n float64, burst int) Limit {
+	return Limit{Rate: n, Burst: burst}
+}
+
+// unlimited 未配置限制
+func (l Limit) unlimited() bool {
+	return l.Rate <= 0
+}
+
+// Rule 方法的限流规则
+type Rule struct {
+	Calls    Limit // 单次调用和打开流的速率
+	Messages Limit // 流调用中接收消息的速率，同一调用方的多个流共用
+}
+
+// KeyFunc 返回调用方标识，返回空字符串时使用对端 IP
+type KeyFunc func(ctx context.Context) string
+
+// ByPeerIP 以对端 IP 区分调用方，不包含端口
+func ByPeerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	addr := p.Addr.String()
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
+
+// BySubject 以认证通过的 token subject 区分调用方，需要在 jwtauth 拦截器之后执行
+func BySubject(ctx context.Context) string {
+	if claims, ok := jwtauth.FromContext(ctx); ok && claims.Subject != "" {
+		return "sub:" + claims.Subject
+	}
+	return ""
+}
+
+// ByMetadata 以请求 metadata 中 key 的值区分调用方，如 x-api-key
+func ByMetadata(key string) KeyFunc {
+	return func(ctx context.Context) string {
+		if v := metadata.ValueFromIncomingContext(ctx, key); len(v) > 0 && v[0] != "" {
+			return key + ":" + v[0]
+		}
+		return ""
+	}
+}
+
+// rule 匹配 pattern 的限流规则
+type rule struct {
+	pattern string
+	Rule
+}
+
+// Option 限流配置项
+type Option func(*Limiter)
+
+// WithRule 指定匹配 pattern（path.Match 格式，如 /protos.PingPong/*）的方法的限流规则，按添加顺序匹配第一个
+func WithRule(pattern string, r Rule) Option {
+	return func(l *Limiter) {
+		l.rules = append(l.rules, rule{pattern: pattern, Rule: r})
+	}
+}
+
+// WithDefaultRule 没有匹配规则的方法使用的限流规则，默认不限制
+func WithDefaultRule(r Rule) Option {
+	return func(l *Limiter) {
+		l.defaultRule = r
+	}
+}
+
+// WithKeyFunc 指定调用方标识，默认 ByPeerIP
+func WithKeyFunc(f KeyFunc) Option {
+	return func(l *Limiter) {
+		l.keyFunc = f
+	}
+}
+
+// WithTimeFunc 设置计算令牌使用的当前时间，用于测试
+func WithTimeFunc(now func() time.Time) Option {
+	return func(l *Limiter) {
+		l.now = now
+	}
+}
+
+// bucketKey 令牌桶标识
+type bucketKey struct {
+	method, caller string
+	messages       bool
+}
+
+type bucket struct {
+	limiter *rate.Limiter
+	burst   int
+}
+
+// Limiter 服务端限流
+type Limiter struct {
+	rules       []rule
+	defaultRule Rule
+	keyFunc     KeyFunc
+	now         func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[bucketKey]*bucket
+	lastSweep time.Time
+}
+
+// New 创建 Limiter，pattern 格式错误时返回错误
+func New(opts ...Option) (*Limiter, error) {
+	l := &Limiter{
+		keyFunc: ByPeerIP,
+		now:     time.Now,
+		buckets: map[bucketKey]*bucket{},
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	for _, r := range l.rules {
+		if _, err := path.Match(r.pattern, ""); err != nil {
+			return nil, fmt.Errorf("ratelimit: invalid pattern %q: %w", r.pattern, err)
+		}
+	}
+	return l, nil
+}
+
+// rule 返回方法的限流规则
+func (l *Limiter) rule(method string) Rule {
+	for _, r := range l.rules {
+		if ok, _ := path.Match(r.pattern, method); ok {
+			return r.Rule
+		}
+	}
+	return l.defaultRule
+}
+
+// caller 返回调用方标识
+func (l *Limiter) caller(ctx context.Context) string {
+	if key := l.keyFunc(ctx); key != "" {
+		return key
+	}
+	if ip := ByPeerIP(ctx); ip != "" {
+		return "ip:" + ip
+	}
+	return "unknown"
+}
+
+// allow 从令牌桶中取一个令牌，没有令牌时返回需要等待的时间
+func (l *Limiter) allow(key bucketKey, limit Limit) (bool, time.Duration) {
+	if limit.unlimited() {
+		return true, 0
+	}
+	now := l.now()
+
+	l.mu.Lock()
+	l.sweep(now)
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{limiter: rate.NewLimiter(rate.Limit(limit.Rate), limit.Burst), burst: limit.Burst}
+		l.buckets[key] = b
+	}
+	l.mu.Unlock()
+
+	r := b.limiter.ReserveN(now, 1)
+	if !r.OK() {
+		// 桶容量为 0，始终拒绝
+		return false, 0
+	}
+	if delay := r.DelayFrom(now); delay > 0 {
+		r.CancelAt(now)
+		return false, delay
+	}
+	return true, 0
+}
+
+// sweep 定期删除已经装满的令牌桶，装满的令牌桶与新建的效果相同，调用方需持有锁
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepInterval {
+		return
+	}
+	l.lastSweep = now
+	for key, b := range l.buckets {
+		if b.limiter.TokensAt(now) >= float64(b.burst) {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// rejected 超过限制的错误，包含 RetryInfo 和 QuotaFailure，trailer 中的 retry-after 向上取整到秒
+func rejected(method string, delay time.Duration) (metadata.MD, error) {
+	md := metadata.MD{}
+	b := rpcerr.Newf(codes.ResourceExhausted, "%s rate limit exceeded", method).
+		WithReason(rpcerr.ReasonRateLimited, map[string]string{"method": method}).
+		WithQuotaViolation(method, "rate limit exceeded")
+	if delay <= 0 {
+		return md, b.Err()
+	}
+	seconds := int64(math.Ceil(delay.Seconds()))
+	md.Set(RetryAfterKey, strconv.FormatInt(seconds, 10))
+	return md, b.WithRetryDelay(delay).Err()
+}
+
+// RetryAfter 读取 trailer 中的等待时间
+func RetryAfter(trailer metadata.MD) (time.Duration, bool) {
+	v := trailer.Get(RetryAfterKey)
+	if len(v) == 0 {
+		return 0, false
+	}
+	seconds, err := strconv.ParseInt(v[0], 10, 64)
+	if err != nil || seconds < 0 {
+		return 0, false
+	}
+	return time.Duration(seconds) * time.Second, true
+}
+
+// UnaryServerInterceptor 服务端拦截器 - 按调用方和方法限制调用速率
+func (l *Limiter) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		key := bucketKey{method: info.FullMethod, caller: l.caller(ctx)}
+		if ok, delay := l.allow(key, l.rule(info.FullMethod).Calls); !ok {
+			md, err := rejected(info.FullMethod, delay)
+			grpc.SetTrailer(ctx, md)
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor 服务端流拦截器 - 限制打开流的速率和流中接收消息的速率
+//
+// 接收消息超过限制时 RecvMsg 返回 codes.ResourceExhausted，服务方法返回该错误后流结束。
+func (l *Limiter) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		r := l.rule(info.FullMethod)
+		key := bucketKey{method: info.FullMethod, caller: l.caller(ss.Context())}
+		if ok, delay := l.allow(key, r.Calls); !ok {
+			md, err := rejected(info.FullMethod, delay)
+			ss.SetTrailer(md)
+			return err
+		}
+		if r.Messages.unlimited() {
+			return handler(srv, ss)
+		}
+		key.messages = true
+		return handler(srv, &limitServerStream{ServerStream: ss, limiter: l, key: key, limit: r.Messages})
+	}
+}
+
+// limitServerStream 包装 grpc.ServerStream，限制接收消息的速率
+type limitServerStream struct {
+	grpc.ServerStream
+	limiter *Limiter
+	key     bucketKey
+	limit   Limit
+}
+
+func (s *limitServerStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	if ok, delay := s.limiter.allow(s.key, s.limit); !ok {
+		md, err := rejected(s.key.method, delay)
+		s.SetTrailer(md)
+		return err
+	}
+	return nil
+}
+
+// Middleware 服务端限流拦截器，用于 middleware.Registry
+func (l *Limiter) Middleware() middleware.Middleware {
+	return middleware.Middleware{
+		UnaryServer:  l.UnaryServerInterceptor(),
+		StreamServer: l.StreamServerInterceptor(),
+	}
+}
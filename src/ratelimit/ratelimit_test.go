@@ -0,0 +1,206 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/jergoo/go-grpc-tutorial/pingpong"
+	"github.com/jergoo/go-grpc-tutorial/pingtest"
+	pb "github.com/jergoo/go-grpc-tutorial/protos/ping" // 引入编译生成的包
+	"github.com/jergoo/go-grpc-tutorial/rpcerr"
+)
+
+// clock 测试使用的时间
+type clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *clock) Add(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func newTestServer(t *testing.T, opts ...Option) (*pingtest.Server, *clock) {
+	c := &clock{now: time.Unix(0, 0)}
+	l, err := New(append([]Option{WithTimeFunc(c.Now), WithKeyFunc(ByMetadata("x-api-key"))}, opts...)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := pingtest.NewServer(t, pingpong.NewPingPongServer(pingpong.WithBatchSize(1)), pingtest.WithServerOptions(
+		grpc.UnaryInterceptor(l.UnaryServerInterceptor()),
+		grpc.StreamInterceptor(l.StreamServerInterceptor()),
+	))
+	return srv, c
+}
+
+func callerContext(caller string) context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), "x-api-key", caller)
+}
+
+// ping 返回状态码和 trailer 中的等待时间，被拒绝时检查错误详情中的 RetryInfo
+func ping(t *testing.T, client pb.PingPongClient, caller string) (codes.Code, time.Duration) {
+	var trailer metadata.MD
+	_, err := client.Ping(callerContext(caller), &pb.PingRequest{Value: "ping"}, grpc.Trailer(&trailer))
+	retryAfter, _ := RetryAfter(trailer)
+	if e, ok := rpcerr.FromError(err); ok {
+		if !errors.Is(e, rpcerr.ErrRateLimited) || e.RetryDelay <= 0 || e.RetryDelay > retryAfter {
+			t.Errorf("details = %+v, retry after = %s", e, retryAfter)
+		}
+	}
+	return status.Code(err), retryAfter
+}
+
+// multiPingPong 发送 n 条消息，返回状态码、收到的响应数和 trailer 中的等待时间
+func multiPingPong(client pb.PingPongClient, caller string, n int) (codes.Code, int, time.Duration) {
+	stream, err := client.MultiPingPong(callerContext(caller))
+	if err != nil {
+		return status.Code(err), 0, 0
+	}
+	go func() {
+		for i := 0; i < n; i++ {
+			if err := stream.Send(&pb.PingRequest{Value: "ping"}); err != nil {
+				return
+			}
+		}
+		stream.CloseSend()
+	}()
+	received := 0
+	for {
+		if _, err := stream.Recv(); err == io.EOF {
+			return codes.OK, received, 0
+		} else if err != nil {
+			retryAfter, _ := RetryAfter(stream.Trailer())
+			return status.Code(err), received, retryAfter
+		}
+		received++
+	}
+}
+
+func TestUnary(t *testing.T) {
+	srv, c := newTestServer(t, WithRule("/protos.PingPong/Ping", Rule{Calls: PerSecond(0.5, 2)}))
+
+	steps := []struct {
+		name       string
+		caller     string
+		advance    time.Duration
+		code       codes.Code
+		retryAfter time.Duration
+	}{
+		{name: "burst 1", caller: "a", code: codes.OK},
+		{name: "burst 2", caller: "a", code: codes.OK},
+		{name: "exhausted", caller: "a", code: codes.ResourceExhausted, retryAfter: 2 * time.Second},
+		{name: "other caller", caller: "b", code: codes.OK},
+		{name: "partial refill", caller: "a", advance: time.Second, code: codes.ResourceExhausted, retryAfter: time.Second},
+		{name: "refilled", caller: "a", advance: time.Second, code: codes.OK},
+	}
+	for _, step := range steps {
+		c.Add(step.advance)
+		code, retryAfter := ping(t, srv.Client, step.caller)
+		if code != step.code || retryAfter != step.retryAfter {
+			t.Errorf("%s: code = %s, retry after = %s, want %s, %s", step.name, code, retryAfter, step.code, step.retryAfter)
+		}
+	}
+}
+
+func TestStream(t *testing.T) {
+	tests := []struct {
+		name       string
+		rule       Rule
+		streams    int
+		messages   int
+		code       codes.Code
+		received   int
+		retryAfter time.Duration
+	}{
+		{name: "unlimited", streams: 3, messages: 5, code: codes.OK, received: 5},
+		{name: "open", rule: Rule{Calls: PerSecond(1, 1)}, streams: 2, messages: 1, code: codes.ResourceExhausted, retryAfter: time.Second},
+		{name: "messages", rule: Rule{Messages: PerSecond(1, 3)}, streams: 1, messages: 5, code: codes.ResourceExhausted, received: 3, retryAfter: time.Second},
+		// 同一调用方的多个流共用消息令牌桶
+		{name: "messages shared", rule: Rule{Messages: PerSecond(1, 3)}, streams: 2, messages: 2, code: codes.ResourceExhausted, received: 1, retryAfter: time.Second},
+		{name: "zero burst", rule: Rule{Calls: PerSecond(1, 0)}, streams: 1, messages: 1, code: codes.ResourceExhausted},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			srv, _ := newTestServer(t, WithRule("/protos.PingPong/MultiPingPong", tt.rule))
+			var (
+				code       codes.Code
+				received   int
+				retryAfter time.Duration
+			)
+			for i := 0; i < tt.streams; i++ {
+				code, received, retryAfter = multiPingPong(srv.Client, "a", tt.messages)
+			}
+			if code != tt.code || received != tt.received || retryAfter != tt.retryAfter {
+				t.Errorf("code = %s, received = %d, retry after = %s, want %s, %d, %s", code, received, retryAfter, tt.code, tt.received, tt.retryAfter)
+			}
+		})
+	}
+}
+
+func TestRule(t *testing.T) {
+	l, err := New(
+		WithRule("/protos.PingPong/Ping", Rule{Calls: PerSecond(10, 10)}),
+		WithRule("/protos.PingPong/*", Rule{Calls: PerSecond(1, 1)}),
+		WithDefaultRule(Rule{Calls: PerSecond(100, 100)}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		method string
+		rate   float64
+	}{
+		{method: "/protos.PingPong/Ping", rate: 10},
+		{method: "/protos.PingPong/MultiPong", rate: 1},
+		{method: "/grpc.health.v1.Health/Check", rate: 100},
+	}
+	for _, tt := range tests {
+		if got := l.rule(tt.method).Calls.Rate; got != tt.rate {
+			t.Errorf("%s rate = %v, want %v", tt.method, got, tt.rate)
+		}
+	}
+
+	if _, err := New(WithRule("[", Rule{})); err == nil {
+		t.Error("invalid pattern accepted")
+	}
+}
+
+func TestSweep(t *testing.T) {
+	c := &clock{now: time.Unix(0, 0)}
+	l, _ := New(WithTimeFunc(c.Now))
+	limit := PerSecond(1, 2)
+	l.allow(bucketKey{method: "/a", caller: "a"}, limit)
+	l.allow(bucketKey{method: "/b", caller: "b"}, limit)
+
+	// 清理时 /a 的令牌桶已装满，/b 在清理前刚取过令牌
+	c.Add(sweepInterval - time.Second)
+	l.allow(bucketKey{method: "/b", caller: "b"}, limit)
+	l.allow(bucketKey{method: "/b", caller: "b"}, limit)
+	c.Add(time.Second)
+	l.allow(bucketKey{method: "/c", caller: "c"}, limit)
+	if _, ok := l.buckets[bucketKey{method: "/b", caller: "b"}]; !ok {
+		t.Error("active bucket removed")
+	}
+	if _, ok := l.buckets[bucketKey{method: "/a", caller: "a"}]; ok {
+		t.Error("idle bucket kept")
+	}
+}
@@ -0,0 +1,150 @@
+// Package recovery 捕获服务端处理中的 panic
+//
+// 服务方法或内层拦截器（如包装的 grpc.ServerStream）发生 panic 时，拦截器恢复执行，
+// 记录包含请求 ID 和调用栈的日志、增加 panic 指标，并返回不包含 panic 内容的 codes.Internal 错误，
+// 避免单个请求导致整个进程退出。
+//
+// 请求 ID 在调用 handler 前确定并保存到 context 中，内层的 logging 拦截器使用相同的 ID，
+// 客户端没有携带 x-request-id 时，panic 日志中的 ID 与响应 header 中返回的一致。
+package recovery
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"runtime/debug"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/jergoo/go-grpc-tutorial/logging"
+	"github.com/jergoo/go-grpc-tutorial/metrics"
+	"github.com/jergoo/go-grpc-tutorial/middleware"
+	"github.com/jergoo/go-grpc-tutorial/requestid"
+)
+
+// 日志字段
+const (
+	KeyPanic = "panic"
+	KeyStack = "stack"
+)
+
+// ErrInternal 默认返回给客户端的错误，不包含 panic 内容
+var ErrInternal = status.Error(codes.Internal, "internal server error")
+
+// HandlerFunc 自定义 panic 处理，p 为 panic 的值，stack 为调用栈，返回的错误发送给客户端
+type HandlerFunc func(ctx context.Context, p interface{}, stack []byte) error
+
+// options 恢复配置
+type options struct {
+	logger  *slog.Logger
+	metrics *metrics.ServerMetrics
+	handler HandlerFunc
+}
+
+// Option 恢复配置项
+type Option func(*options)
+
+// WithLogger 指定 logger，默认输出 JSON 格式到标准错误
+func WithLogger(l *slog.Logger) Option {
+	return func(o *options) {
+		o.logger = l
+	}
+}
+
+// WithMetrics 记录 panic 次数到服务端指标 grpc_server_panics_recovered_total
+func WithMetrics(m *metrics.ServerMetrics) Option {
+	return func(o *options) {
+		o.metrics = m
+	}
+}
+
+// WithHandler 自定义返回给客户端的错误，日志和指标仍然记录，handler 返回 nil 时使用 ErrInternal
+func WithHandler(h HandlerFunc) Option {
+	return func(o *options) {
+		o.handler = h
+	}
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		logger: slog.New(slog.NewJSONHandler(os.Stderr, nil)),
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// recover 处理 panic 并返回发送给客户端的错误
+func (o *options) recover(ctx context.Context, fullMethod string, p interface{}) error {
+	stack := debug.Stack()
+	ctx, id := requestid.Incoming(ctx)
+	o.logger.LogAttrs(ctx, slog.LevelError, "panic recovered",
+		slog.String(logging.KeyMethod, fullMethod),
+		slog.String(logging.KeyRequestID, id),
+		slog.String(KeyPanic, fmt.Sprint(p)),
+		slog.String(KeyStack, string(stack)),
+	)
+	if o.metrics != nil {
+		o.metrics.PanicRecovered(fullMethod)
+	}
+	if o.handler != nil {
+		if err := o.handler(ctx, p, stack); err != nil {
+			return err
+		}
+	}
+	return ErrInternal
+}
+
+// UnaryServerInterceptor 服务端拦截器 - 捕获 panic 并返回 codes.Internal
+func UnaryServerInterceptor(opts ...Option) grpc.UnaryServerInterceptor {
+	o := newOptions(opts)
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		// 在调用 handler 前确定请求 ID，内层的日志拦截器和 panic 日志使用相同的 ID
+		ctx, _ = requestid.Incoming(ctx)
+		defer func() {
+			if p := recover(); p != nil {
+				resp, err = nil, o.recover(ctx, info.FullMethod, p)
+			}
+		}()
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor 服务端流拦截器 - 捕获 panic 并返回 codes.Internal
+//
+// 服务方法和内层拦截器包装的 grpc.ServerStream 中的 panic 都会被捕获，
+// 服务方法另外启动的 goroutine 中的 panic 无法捕获。
+func StreamServerInterceptor(opts ...Option) grpc.StreamServerInterceptor {
+	o := newOptions(opts)
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		ctx, _ := requestid.Incoming(ss.Context())
+		defer func() {
+			if p := recover(); p != nil {
+				err = o.recover(ctx, info.FullMethod, p)
+			}
+		}()
+		return handler(srv, &recoveryServerStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// recoveryServerStream 包装 grpc.ServerStream，Context 中保存了请求 ID
+type recoveryServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *recoveryServerStream) Context() context.Context {
+	return s.ctx
+}
+
+// Middleware 服务端恢复拦截器，用于 middleware.Registry
+func Middleware(opts ...Option) middleware.Middleware {
+	return middleware.Middleware{
+		UnaryServer:  UnaryServerInterceptor(opts...),
+		StreamServer: StreamServerInterceptor(opts...),
+	}
+}
@@ -0,0 +1,266 @@
+package recovery
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/jergoo/go-grpc-tutorial/logging"
+	"github.com/jergoo/go-grpc-tutorial/metrics"
+	"github.com/jergoo/go-grpc-tutorial/pingpong"
+	"github.com/jergoo/go-grpc-tutorial/pingtest"
+	pb "github.com/jergoo/go-grpc-tutorial/protos/ping" // 引入编译生成的包
+	"github.com/jergoo/go-grpc-tutorial/requestid"
+)
+
+// syncBuffer 并发安全的日志输出
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+// records 返回 panic 日志
+func (b *syncBuffer) records(t *testing.T) []map[string]interface{} {
+	t.Helper()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var out []map[string]interface{}
+	dec := json.NewDecoder(&b.buf)
+	for {
+		var r map[string]interface{}
+		if err := dec.Decode(&r); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		if r["msg"] == "panic recovered" {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// panicServer 服务方法直接 panic
+type panicServer struct {
+	pb.UnimplementedPingPongServer
+}
+
+func (panicServer) Ping(context.Context, *pb.PingRequest) (*pb.PongResponse, error) {
+	panic("ping failed")
+}
+
+func (panicServer) MultiPong(*pb.PingRequest, pb.PingPong_MultiPongServer) error {
+	panic("multi pong failed")
+}
+
+// panicStream 发送消息时 panic 的 grpc.ServerStream
+type panicStream struct {
+	grpc.ServerStream
+}
+
+func (s *panicStream) SendMsg(m interface{}) error {
+	panic("send failed")
+}
+
+// panicStreamInterceptor 内层拦截器，使用发送时 panic 的 grpc.ServerStream
+func panicStreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return handler(srv, &panicStream{ss})
+}
+
+func newTestServer(t *testing.T, impl pb.PingPongServer, inner []grpc.StreamServerInterceptor, opts ...Option) (*pingtest.Server, *syncBuffer, *metrics.ServerMetrics) {
+	buf := &syncBuffer{}
+	m := metrics.NewServerMetrics()
+	opts = append([]Option{WithLogger(slog.New(slog.NewJSONHandler(buf, nil))), WithMetrics(m)}, opts...)
+	srv := pingtest.NewServer(t, impl, pingtest.WithServerOptions(
+		grpc.UnaryInterceptor(UnaryServerInterceptor(opts...)),
+		grpc.ChainStreamInterceptor(append([]grpc.StreamServerInterceptor{StreamServerInterceptor(opts...)}, inner...)...),
+	))
+	return srv, buf, m
+}
+
+func multiPong(ctx context.Context, client pb.PingPongClient) error {
+	stream, err := client.MultiPong(ctx, &pb.PingRequest{Value: "ping"})
+	if err != nil {
+		return err
+	}
+	for {
+		if _, err := stream.Recv(); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
+
+func TestRecovery(t *testing.T) {
+	tests := []struct {
+		name   string
+		impl   pb.PingPongServer
+		inner  []grpc.StreamServerInterceptor
+		method string
+		call   func(ctx context.Context, client pb.PingPongClient) error
+		panic  string
+	}{
+		{
+			name: "unary", impl: panicServer{}, method: "Ping", panic: "ping failed",
+			call: func(ctx context.Context, client pb.PingPongClient) error {
+				_, err := client.Ping(ctx, &pb.PingRequest{Value: "ping"})
+				return err
+			},
+		},
+		{
+			name: "stream", impl: panicServer{}, method: "MultiPong", panic: "multi pong failed",
+			call: multiPong,
+		},
+		{
+			name: "stream wrapper", impl: pingpong.NewPingPongServer(), method: "MultiPong", panic: "send failed",
+			inner: []grpc.StreamServerInterceptor{panicStreamInterceptor},
+			call:  multiPong,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			srv, buf, m := newTestServer(t, tt.impl, tt.inner)
+			ctx := metadata.AppendToOutgoingContext(context.Background(), requestid.Key, "req-"+tt.method)
+			err := tt.call(ctx, srv.Client)
+			if st := status.Convert(err); st.Code() != codes.Internal || st.Message() != "internal server error" {
+				t.Fatalf("err = %v", err)
+			}
+			if strings.Contains(err.Error(), tt.panic) {
+				t.Errorf("panic value leaked to client: %v", err)
+			}
+
+			records := buf.records(t)
+			if len(records) != 1 {
+				t.Fatalf("records = %d", len(records))
+			}
+			r := records[0]
+			if r[logging.KeyRequestID] != "req-"+tt.method || r[KeyPanic] != tt.panic || r[logging.KeyMethod] != "/protos.PingPong/"+tt.method {
+				t.Errorf("record = %v", r)
+			}
+			if stack, _ := r[KeyStack].(string); !strings.Contains(stack, "recovery_test.go") {
+				t.Errorf("stack = %s", stack)
+			}
+			want := `
+# HELP grpc_server_panics_recovered_total Total number of panics recovered in RPC handlers.
+# TYPE grpc_server_panics_recovered_total counter
+grpc_server_panics_recovered_total{grpc_method="` + tt.method + `",grpc_service="protos.PingPong"} 1
+`
+			if err := testutil.CollectAndCompare(m, strings.NewReader(want), "grpc_server_panics_recovered_total"); err != nil {
+				t.Error(err)
+			}
+
+			// panic 后服务继续运行
+			if _, err := srv.Client.Ping(context.Background(), &pb.PingRequest{Value: "ping"}); err != nil && status.Code(err) != codes.Internal {
+				t.Errorf("server stopped: %v", err)
+			}
+		})
+	}
+}
+
+func TestRequestIDGenerated(t *testing.T) {
+	tests := []struct {
+		name string
+		call func(ctx context.Context, client pb.PingPongClient, header *metadata.MD) error
+	}{
+		{
+			name: "unary",
+			call: func(ctx context.Context, client pb.PingPongClient, header *metadata.MD) error {
+				_, err := client.Ping(ctx, &pb.PingRequest{Value: "ping"}, grpc.Header(header))
+				return err
+			},
+		},
+		{
+			name: "stream",
+			call: func(ctx context.Context, client pb.PingPongClient, header *metadata.MD) error {
+				stream, err := client.MultiPong(ctx, &pb.PingRequest{Value: "ping"})
+				if err != nil {
+					return err
+				}
+				_, err = stream.Recv()
+				*header, _ = stream.Header()
+				return err
+			},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			buf := &syncBuffer{}
+			opts := []Option{WithLogger(slog.New(slog.NewJSONHandler(buf, nil)))}
+			logOpts := []logging.Option{logging.WithLogger(slog.New(slog.NewJSONHandler(io.Discard, nil)))}
+			// recovery 在 logging 外层，客户端不携带请求 ID
+			srv := pingtest.NewServer(t, panicServer{}, pingtest.WithServerOptions(
+				grpc.ChainUnaryInterceptor(UnaryServerInterceptor(opts...), logging.UnaryServerInterceptor(logOpts...)),
+				grpc.ChainStreamInterceptor(StreamServerInterceptor(opts...), logging.StreamServerInterceptor(logOpts...)),
+			))
+			var header metadata.MD
+			if err := tt.call(context.Background(), srv.Client, &header); status.Code(err) != codes.Internal {
+				t.Fatalf("err = %v", err)
+			}
+
+			records := buf.records(t)
+			if len(records) != 1 {
+				t.Fatalf("records = %d", len(records))
+			}
+			id := header.Get(requestid.Key)
+			if len(id) != 1 || id[0] == "" || records[0][logging.KeyRequestID] != id[0] {
+				t.Errorf("header request id = %v, panic log request id = %v", id, records[0][logging.KeyRequestID])
+			}
+		})
+	}
+}
+
+func TestHandler(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler HandlerFunc
+		code    codes.Code
+	}{
+		{
+			name: "custom",
+			handler: func(ctx context.Context, p interface{}, stack []byte) error {
+				id, _ := requestid.FromContext(ctx)
+				return status.Errorf(codes.Unavailable, "retry later, request id %s", id)
+			},
+			code: codes.Unavailable,
+		},
+		{
+			name:    "nil",
+			handler: func(ctx context.Context, p interface{}, stack []byte) error { return nil },
+			code:    codes.Internal,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, buf, _ := newTestServer(t, panicServer{}, nil, WithHandler(tt.handler))
+			_, err := srv.Client.Ping(context.Background(), &pb.PingRequest{Value: "ping"})
+			if status.Code(err) != tt.code {
+				t.Fatalf("err = %v", err)
+			}
+			if len(buf.records(t)) != 1 {
+				t.Error("panic not logged")
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+// Package requestid 请求 ID 的生成和传递
+//
+// 请求 ID 通过 metadata 的 x-request-id 在客户端和服务端之间传递，服务端没有收到时生成新的 ID。
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// Key 请求 ID 的 metadata key
+const Key = "x-request-id"
+
+type requestIDKey struct{}
+
+// New 生成随机请求 ID
+func New() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// NewContext 返回保存了 id 的 context
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// FromContext 获取 context 中的请求 ID
+func FromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(requestIDKey{}).(string)
+	return id, ok && id != ""
+}
+
+// Incoming 服务端获取请求 ID，依次读取 context、请求 metadata，都没有时生成新的 ID
+//
+// 返回的 context 保存了请求 ID，重复调用返回相同的 ID。
+func Incoming(ctx context.Context) (context.Context, string) {
+	if id, ok := FromContext(ctx); ok {
+		return ctx, id
+	}
+	id := ""
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if v := md.Get(Key); len(v) > 0 {
+			id = v[0]
+		}
+	}
+	if id == "" {
+		id = New()
+	}
+	return NewContext(ctx, id), id
+}
+
+// Outgoing 客户端获取请求 ID 并添加到请求 metadata，依次读取 context、已设置的请求 metadata，都没有时生成新的 ID
+func Outgoing(ctx context.Context) (context.Context, string) {
+	if md, ok := metadata.FromOutgoingContext(ctx); ok {
+		if v := md.Get(Key); len(v) > 0 && v[0] != "" {
+			return NewContext(ctx, v[0]), v[0]
+		}
+	}
+	id, ok := FromContext(ctx)
+	if !ok {
+		id = New()
+		ctx = NewContext(ctx, id)
+	}
+	return metadata.AppendToOutgoingContext(ctx, Key, id), id
+}
@@ -0,0 +1,55 @@
+package requestid
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc/metadata"
+)
+
+func TestIncoming(t *testing.T) {
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want string
+	}{
+		{name: "context", ctx: NewContext(context.Background(), "ctx"), want: "ctx"},
+		{name: "metadata", ctx: metadata.NewIncomingContext(context.Background(), metadata.Pairs(Key, "md")), want: "md"},
+		{name: "generated", ctx: context.Background()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, id := Incoming(tt.ctx)
+			if id == "" || (tt.want != "" && id != tt.want) {
+				t.Fatalf("id = %q, want %q", id, tt.want)
+			}
+			if _, again := Incoming(ctx); again != id {
+				t.Errorf("second id = %q, want %q", again, id)
+			}
+		})
+	}
+}
+
+func TestOutgoing(t *testing.T) {
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want string
+	}{
+		{name: "context", ctx: NewContext(context.Background(), "ctx"), want: "ctx"},
+		{name: "metadata", ctx: metadata.AppendToOutgoingContext(context.Background(), Key, "md"), want: "md"},
+		{name: "generated", ctx: context.Background()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, id := Outgoing(tt.ctx)
+			if id == "" || (tt.want != "" && id != tt.want) {
+				t.Fatalf("id = %q, want %q", id, tt.want)
+			}
+			md, _ := metadata.FromOutgoingContext(ctx)
+			if got := md.Get(Key); len(got) != 1 || got[0] != id {
+				t.Errorf("metadata = %v, want [%s]", got, id)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package rpcerr
+
+import (
+	"context"
+	"io"
+
+	"google.golang.org/grpc"
+)
+
+// convert 将 gRPC 错误转换为 *Error，io.EOF 等其他错误原样返回
+func convert(err error) error {
+	if err == nil || err == io.EOF {
+		return err
+	}
+	if e, ok := FromError(err); ok {
+		return e
+	}
+	return err
+}
+
+// UnaryClientInterceptor 客户端拦截器 - 调用返回的错误转换为 *Error
+func UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return convert(invoker(ctx, method, req, reply, cc, opts...))
+	}
+}
+
+// StreamClientInterceptor 客户端流拦截器 - 打开流和收发消息返回的错误转换为 *Error
+func StreamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		cs, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			return nil, convert(err)
+		}
+		return &errorClientStream{cs}, nil
+	}
+}
+
+// errorClientStream 包装 grpc.ClientStream，转换收发消息返回的错误
+type errorClientStream struct {
+	grpc.ClientStream
+}
+
+func (s *errorClientStream) SendMsg(m interface{}) error {
+	return convert(s.ClientStream.SendMsg(m))
+}
+
+func (s *errorClientStream) RecvMsg(m interface{}) error {
+	return convert(s.ClientStream.RecvMsg(m))
+}
@@ -0,0 +1,222 @@
+// Package rpcerr 基于 google.rpc.Status 的结构化错误
+//
+// 服务端使用 Builder 构造带有 errdetails（ErrorInfo、BadRequest、QuotaFailure、RetryInfo、LocalizedMessage）的错误，
+// 客户端使用 FromError 或客户端拦截器将其转换为 *Error，通过字段读取详情，或使用 errors.Is 与预定义的错误比较。
+package rpcerr
+
+import (
+	"fmt"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// Domain ErrorInfo 的默认 domain
+const Domain = "go-grpc-tutorial"
+
+// ErrorInfo 的错误原因
+const (
+	ReasonAuthorizationMissing = "AUTHORIZATION_MISSING"
+	ReasonTokenInvalid         = "TOKEN_INVALID"
+	ReasonInvalidRequest       = "INVALID_REQUEST"
+	ReasonRateLimited          = "RATE_LIMITED"
+	ReasonPingLimitExceeded    = "PING_LIMIT_EXCEEDED"
+	ReasonResumeUnavailable    = "RESUME_UNAVAILABLE"
+)
+
+// 预定义错误，用于 errors.Is 比较状态码和错误原因
+var (
+	ErrAuthorizationMissing = &Error{Code: codes.Unauthenticated, Reason: ReasonAuthorizationMissing}
+	ErrTokenInvalid         = &Error{Code: codes.Unauthenticated, Reason: ReasonTokenInvalid}
+	ErrInvalidRequest       = &Error{Code: codes.InvalidArgument, Reason: ReasonInvalidRequest}
+	ErrRateLimited          = &Error{Code: codes.ResourceExhausted, Reason: ReasonRateLimited}
+	ErrPingLimitExceeded    = &Error{Code: codes.ResourceExhausted, Reason: ReasonPingLimitExceeded}
+	ErrResumeUnavailable    = &Error{Code: codes.FailedPrecondition, Reason: ReasonResumeUnavailable}
+)
+
+// Builder 构造带有详情的错误
+type Builder struct {
+	code    codes.Code
+	msg     string
+	info    *errdetails.ErrorInfo
+	bad     *errdetails.BadRequest
+	quota   *errdetails.QuotaFailure
+	retry   *errdetails.RetryInfo
+	message *errdetails.LocalizedMessage
+}
+
+// New 创建状态码为 code 的错误
+func New(code codes.Code, msg string) *Builder {
+	return &Builder{code: code, msg: msg}
+}
+
+// Newf 创建状态码为 code 的错误，msg 按 format 格式化
+func Newf(code codes.Code, format string, args ...interface{}) *Builder {
+	return New(code, fmt.Sprintf(format, args...))
+}
+
+// WithReason 添加 ErrorInfo，domain 为 Domain
+func (b *Builder) WithReason(reason string, metadata map[string]string) *Builder {
+	b.info = &errdetails.ErrorInfo{Reason: reason, Domain: Domain, Metadata: metadata}
+	return b
+}
+
+// WithFieldViolation 添加 BadRequest 中的字段错误
+func (b *Builder) WithFieldViolation(field, description string) *Builder {
+	if b.bad == nil {
+		b.bad = &errdetails.BadRequest{}
+	}
+	b.bad.FieldViolations = append(b.bad.FieldViolations, &errdetails.BadRequest_FieldViolation{Field: field, Description: description})
+	return b
+}
+
+// WithQuotaViolation 添加 QuotaFailure 中超出的配额
+func (b *Builder) WithQuotaViolation(subject, description string) *Builder {
+	if b.quota == nil {
+		b.quota = &errdetails.QuotaFailure{}
+	}
+	b.quota.Violations = append(b.quota.Violations, &errdetails.QuotaFailure_Violation{Subject: subject, Description: description})
+	return b
+}
+
+// WithRetryDelay 添加 RetryInfo，客户端至少等待 d 后重试
+func (b *Builder) WithRetryDelay(d time.Duration) *Builder {
+	b.retry = &errdetails.RetryInfo{RetryDelay: durationpb.New(d)}
+	return b
+}
+
+// WithLocalizedMessage 添加面向用户的本地化错误信息，locale 如 zh-CN
+func (b *Builder) WithLocalizedMessage(locale, msg string) *Builder {
+	b.message = &errdetails.LocalizedMessage{Locale: locale, Message: msg}
+	return b
+}
+
+// Status 返回包含详情的 *status.Status
+func (b *Builder) Status() *status.Status {
+	st := status.New(b.code, b.msg)
+	var details []protoadapt.MessageV1
+	if b.info != nil {
+		details = append(details, b.info)
+	}
+	if b.bad != nil {
+		details = append(details, b.bad)
+	}
+	if b.quota != nil {
+		details = append(details, b.quota)
+	}
+	if b.retry != nil {
+		details = append(details, b.retry)
+	}
+	if b.message != nil {
+		details = append(details, b.message)
+	}
+	if len(details) == 0 {
+		return st
+	}
+	if ds, err := st.WithDetails(details...); err == nil {
+		return ds
+	}
+	return st
+}
+
+// Err 返回 gRPC 错误，code 为 codes.OK 时返回 nil
+func (b *Builder) Err() error {
+	return b.Status().Err()
+}
+
+// FieldViolation BadRequest 中的字段错误
+type FieldViolation struct {
+	Field       string
+	Description string
+}
+
+// QuotaViolation QuotaFailure 中超出的配额
+type QuotaViolation struct {
+	Subject     string
+	Description string
+}
+
+// Error 客户端解析后的错误
+type Error struct {
+	Code    codes.Code
+	Message string
+
+	// ErrorInfo
+	Reason   string
+	Domain   string
+	Metadata map[string]string
+
+	FieldViolations  []FieldViolation
+	QuotaViolations  []QuotaViolation
+	RetryDelay       time.Duration // 没有 RetryInfo 时为 0
+	LocalizedMessage string
+
+	status *status.Status
+}
+
+func (e *Error) Error() string {
+	if e.Reason != "" {
+		return fmt.Sprintf("rpc error: code = %s reason = %s desc = %s", e.Code, e.Reason, e.Message)
+	}
+	return fmt.Sprintf("rpc error: code = %s desc = %s", e.Code, e.Message)
+}
+
+// GRPCStatus 返回原始状态，status.Code、status.Convert 等函数可以直接使用 *Error
+func (e *Error) GRPCStatus() *status.Status {
+	if e.status != nil {
+		return e.status
+	}
+	return status.New(e.Code, e.Message)
+}
+
+// Is 状态码相同，且 target 的 Reason 为空或相同时返回 true
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	if !ok {
+		return false
+	}
+	return e.Code == t.Code && (t.Reason == "" || e.Reason == t.Reason)
+}
+
+// FromError 将 gRPC 错误转换为 *Error，err 为 nil 或不是 gRPC 错误时返回 false
+func FromError(err error) (*Error, bool) {
+	if err == nil {
+		return nil, false
+	}
+	if e, ok := err.(*Error); ok {
+		return e, true
+	}
+	st, ok := status.FromError(err)
+	if !ok {
+		return nil, false
+	}
+	return FromStatus(st), true
+}
+
+// FromStatus 读取状态中的详情
+func FromStatus(st *status.Status) *Error {
+	e := &Error{Code: st.Code(), Message: st.Message(), status: st}
+	for _, d := range st.Details() {
+		switch d := d.(type) {
+		case *errdetails.ErrorInfo:
+			e.Reason, e.Domain, e.Metadata = d.Reason, d.Domain, d.Metadata
+		case *errdetails.BadRequest:
+			for _, v := range d.FieldViolations {
+				e.FieldViolations = append(e.FieldViolations, FieldViolation{Field: v.Field, Description: v.Description})
+			}
+		case *errdetails.QuotaFailure:
+			for _, v := range d.Violations {
+				e.QuotaViolations = append(e.QuotaViolations, QuotaViolation{Subject: v.Subject, Description: v.Description})
+			}
+		case *errdetails.RetryInfo:
+			e.RetryDelay = d.RetryDelay.AsDuration()
+		case *errdetails.LocalizedMessage:
+			e.LocalizedMessage = d.Message
+		}
+	}
+	return e
+}
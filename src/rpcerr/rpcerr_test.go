@@ -0,0 +1,147 @@
+package rpcerr
+
+import (
+	"context"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/jergoo/go-grpc-tutorial/pingtest"
+	pb "github.com/jergoo/go-grpc-tutorial/protos/ping" // 引入编译生成的包
+)
+
+func TestFromError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want *Error
+		is   error
+	}{
+		{
+			name: "plain",
+			err:  status.Error(codes.NotFound, "not found"),
+			want: &Error{Code: codes.NotFound, Message: "not found"},
+		},
+		{
+			name: "reason",
+			err:  New(codes.Unauthenticated, "authorization missing").WithReason(ReasonAuthorizationMissing, map[string]string{"header": "authorization"}).Err(),
+			want: &Error{Code: codes.Unauthenticated, Message: "authorization missing", Reason: ReasonAuthorizationMissing, Domain: Domain, Metadata: map[string]string{"header": "authorization"}},
+			is:   ErrAuthorizationMissing,
+		},
+		{
+			name: "all details",
+			err: Newf(codes.ResourceExhausted, "limit %d", 5).
+				WithReason(ReasonRateLimited, nil).
+				WithFieldViolation("value", "too long").
+				WithQuotaViolation("Ping", "10 per second").
+				WithRetryDelay(1500 * time.Millisecond).
+				WithLocalizedMessage("zh-CN", "请求过于频繁").
+				Err(),
+			want: &Error{
+				Code: codes.ResourceExhausted, Message: "limit 5", Reason: ReasonRateLimited, Domain: Domain,
+				FieldViolations:  []FieldViolation{{Field: "value", Description: "too long"}},
+				QuotaViolations:  []QuotaViolation{{Subject: "Ping", Description: "10 per second"}},
+				RetryDelay:       1500 * time.Millisecond,
+				LocalizedMessage: "请求过于频繁",
+			},
+			is: ErrRateLimited,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := FromError(tt.err)
+			if !ok {
+				t.Fatal("not a grpc error")
+			}
+			got.status = nil
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+			if tt.is != nil && !errors.Is(got, tt.is) {
+				t.Errorf("%v is not %v", got, tt.is)
+			}
+			// 同一状态码、不同原因
+			if errors.Is(got, &Error{Code: tt.want.Code, Reason: "OTHER"}) {
+				t.Error("matched other reason")
+			}
+		})
+	}
+
+	if _, ok := FromError(nil); ok {
+		t.Error("nil converted")
+	}
+	if _, ok := FromError(io.EOF); ok {
+		t.Error("io.EOF converted")
+	}
+}
+
+// limitServer Ping 和 MultiPong 返回带有详情的错误
+type limitServer struct {
+	pb.UnimplementedPingPongServer
+}
+
+func (limitServer) Ping(context.Context, *pb.PingRequest) (*pb.PongResponse, error) {
+	return nil, New(codes.ResourceExhausted, "slow down").WithReason(ReasonRateLimited, nil).WithRetryDelay(time.Second).Err()
+}
+
+func (limitServer) MultiPong(req *pb.PingRequest, stream pb.PingPong_MultiPongServer) error {
+	stream.Send(&pb.PongResponse{Value: "pong"})
+	return New(codes.ResourceExhausted, "slow down").WithReason(ReasonRateLimited, nil).WithRetryDelay(time.Second).Err()
+}
+
+func TestClientInterceptor(t *testing.T) {
+	srv := pingtest.NewServer(t, limitServer{}, pingtest.WithDialOptions(
+		grpc.WithUnaryInterceptor(UnaryClientInterceptor()),
+		grpc.WithStreamInterceptor(StreamClientInterceptor()),
+	))
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		call func() error
+	}{
+		{
+			name: "unary",
+			call: func() error {
+				_, err := srv.Client.Ping(ctx, &pb.PingRequest{Value: "ping"})
+				return err
+			},
+		},
+		{
+			name: "stream",
+			call: func() error {
+				stream, err := srv.Client.MultiPong(ctx, &pb.PingRequest{Value: "ping"})
+				if err != nil {
+					return err
+				}
+				for {
+					if _, err := stream.Recv(); err != nil {
+						return err
+					}
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.call()
+			var e *Error
+			if !errors.As(err, &e) {
+				t.Fatalf("err = %T %v", err, err)
+			}
+			if !errors.Is(err, ErrRateLimited) || e.RetryDelay != time.Second {
+				t.Errorf("err = %+v", e)
+			}
+			// *Error 仍然可以作为 gRPC 错误使用
+			if status.Code(err) != codes.ResourceExhausted || len(status.Convert(err).Details()) != 2 {
+				t.Errorf("status = %v", status.Convert(err))
+			}
+		})
+	}
+}
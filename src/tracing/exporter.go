@@ -0,0 +1,62 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// 导出方式
+const (
+	ExporterStdout = "stdout"
+	ExporterOTLP   = "otlp"
+)
+
+// DefaultOTLPEndpoint 本地 OpenTelemetry Collector 的 OTLP/gRPC 地址
+const DefaultOTLPEndpoint = "localhost:4317"
+
+// NewTracerProvider 创建 TracerProvider，span 批量导出到 exporter，serviceName 作为资源属性 service.name
+//
+// 程序退出前需要调用 Shutdown 导出剩余的 span。
+func NewTracerProvider(serviceName string, exporter sdktrace.SpanExporter) (*sdktrace.TracerProvider, error) {
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(attribute.String("service.name", serviceName)))
+	if err != nil {
+		return nil, err
+	}
+	return sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter), sdktrace.WithResource(res)), nil
+}
+
+// NewStdoutExporter 以 JSON 格式输出 span 到 w，用于本地调试
+func NewStdoutExporter(w io.Writer) (sdktrace.SpanExporter, error) {
+	return stdouttrace.New(stdouttrace.WithWriter(w), stdouttrace.WithPrettyPrint())
+}
+
+// NewOTLPExporter 通过 OTLP/gRPC 导出 span 到 endpoint，如本地的 OpenTelemetry Collector，不使用 TLS
+func NewOTLPExporter(ctx context.Context, endpoint string) (sdktrace.SpanExporter, error) {
+	return otlptracegrpc.New(ctx, otlptracegrpc.WithEndpoint(endpoint), otlptracegrpc.WithInsecure())
+}
+
+// NewExporter 按名称创建 exporter，name 为 stdout 或 otlp，endpoint 只用于 otlp
+func NewExporter(ctx context.Context, name string, w io.Writer, endpoint string) (sdktrace.SpanExporter, error) {
+	switch name {
+	case ExporterStdout:
+		return NewStdoutExporter(w)
+	case ExporterOTLP:
+		return NewOTLPExporter(ctx, endpoint)
+	default:
+		return nil, fmt.Errorf("unknown exporter %q", name)
+	}
+}
+
+// NewInMemory 创建同步导出到内存的 TracerProvider，用于单元测试检查生成的 span
+func NewInMemory() (*sdktrace.TracerProvider, *tracetest.InMemoryExporter) {
+	exporter := tracetest.NewInMemoryExporter()
+	return sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)), exporter
+}
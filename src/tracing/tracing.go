@@ -0,0 +1,329 @@
+// Package tracing 基于 OpenTelemetry 的调用链追踪拦截器
+//
+// 客户端为每次调用创建 Client span，并通过 metadata 传递 W3C traceparent，
+// 服务端从 metadata 中读取上游 span 信息并创建 Server span，流调用的每条消息记录为 span event。
+package tracing
+
+import (
+	"context"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/jergoo/go-grpc-tutorial/middleware"
+)
+
+// instrumentationName tracer 名称
+const instrumentationName = "github.com/jergoo/go-grpc-tutorial/tracing"
+
+// errPanic 处理方法 panic 时记录的错误，与外层 recovery 拦截器返回的状态码一致
+var errPanic = status.Error(codes.Internal, "panic")
+
+// span 属性，参考 OpenTelemetry RPC 语义约定
+const (
+	RPCSystemKey        = attribute.Key("rpc.system")
+	RPCServiceKey       = attribute.Key("rpc.service")
+	RPCMethodKey        = attribute.Key("rpc.method")
+	RPCStatusCodeKey    = attribute.Key("rpc.grpc.status_code")
+	PeerAddressKey      = attribute.Key("network.peer.address")
+	MessageTypeKey      = attribute.Key("message.type")
+	MessageIDKey        = attribute.Key("message.id")
+	MessageSizeKey      = attribute.Key("message.uncompressed_size")
+	messageEvent        = "message"
+	messageTypeSent     = "SENT"
+	messageTypeReceived = "RECEIVED"
+)
+
+// options 追踪配置
+type options struct {
+	provider   trace.TracerProvider
+	propagator propagation.TextMapPropagator
+}
+
+// Option 追踪配置项
+type Option func(*options)
+
+// WithTracerProvider 指定 TracerProvider，默认使用 otel.GetTracerProvider()
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(o *options) {
+		o.provider = tp
+	}
+}
+
+// WithPropagator 指定跨进程传递 span 信息的格式，默认 W3C Trace Context（traceparent）
+func WithPropagator(p propagation.TextMapPropagator) Option {
+	return func(o *options) {
+		o.propagator = p
+	}
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		provider:   otel.GetTracerProvider(),
+		propagator: propagation.TraceContext{},
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+func (o *options) tracer() trace.Tracer {
+	return o.provider.Tracer(instrumentationName)
+}
+
+// metadataCarrier 使用 gRPC metadata 实现 propagation.TextMapCarrier
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Get(key string) string {
+	if v := metadata.MD(c).Get(key); len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
+
+func (c metadataCarrier) Set(key, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// extract 读取请求 metadata 中的上游 span 信息
+func (o *options) extract(ctx context.Context) context.Context {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ctx
+	}
+	return o.propagator.Extract(ctx, metadataCarrier(md))
+}
+
+// inject 将当前 span 信息写入请求 metadata
+func (o *options) inject(ctx context.Context) context.Context {
+	md, ok := metadata.FromOutgoingContext(ctx)
+	if ok {
+		md = md.Copy()
+	} else {
+		md = metadata.MD{}
+	}
+	o.propagator.Inject(ctx, metadataCarrier(md))
+	return metadata.NewOutgoingContext(ctx, md)
+}
+
+// spanInfo 根据完整方法名生成 span 名称和属性
+func spanInfo(fullMethod, peerAddr string) (string, []attribute.KeyValue) {
+	name := strings.TrimPrefix(fullMethod, "/")
+	attrs := []attribute.KeyValue{RPCSystemKey.String("grpc")}
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		attrs = append(attrs, RPCServiceKey.String(name[:i]), RPCMethodKey.String(name[i+1:]))
+	}
+	if peerAddr != "" {
+		attrs = append(attrs, PeerAddressKey.String(peerAddr))
+	}
+	return name, attrs
+}
+
+// endSpan 记录状态码并结束 span
+func endSpan(span trace.Span, err error) {
+	s := status.Convert(err)
+	span.SetAttributes(RPCStatusCodeKey.Int64(int64(s.Code())))
+	if err != nil {
+		span.SetStatus(otelcodes.Error, s.Message())
+	}
+	span.End()
+}
+
+// messages 记录流消息 event
+type messages struct {
+	span           trace.Span
+	sentID, recvID atomic.Int64
+}
+
+func (m *messages) event(typ string, id int64, msg interface{}) {
+	attrs := []attribute.KeyValue{MessageTypeKey.String(typ), MessageIDKey.Int64(id)}
+	if pm, ok := msg.(proto.Message); ok {
+		attrs = append(attrs, MessageSizeKey.Int(proto.Size(pm)))
+	}
+	m.span.AddEvent(messageEvent, trace.WithAttributes(attrs...))
+}
+
+func (m *messages) sent(msg interface{}) {
+	m.event(messageTypeSent, m.sentID.Add(1), msg)
+}
+
+func (m *messages) received(msg interface{}) {
+	m.event(messageTypeReceived, m.recvID.Add(1), msg)
+}
+
+func peerAddr(ctx context.Context) string {
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		return p.Addr.String()
+	}
+	return ""
+}
+
+// UnaryServerInterceptor 服务端拦截器 - 创建 Server span
+func UnaryServerInterceptor(opts ...Option) grpc.UnaryServerInterceptor {
+	o := newOptions(opts)
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		name, attrs := spanInfo(info.FullMethod, peerAddr(ctx))
+		ctx, span := o.tracer().Start(o.extract(ctx), name, trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(attrs...))
+		// handler panic 时同样结束记录，panic 继续向外传递给 recovery 拦截器
+		done := false
+		defer func() {
+			if !done {
+				endSpan(span, errPanic)
+			}
+		}()
+		resp, err := handler(ctx, req)
+		done = true
+		endSpan(span, err)
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor 服务端流拦截器 - 创建 Server span，每条消息记录为 event
+func StreamServerInterceptor(opts ...Option) grpc.StreamServerInterceptor {
+	o := newOptions(opts)
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx := ss.Context()
+		name, attrs := spanInfo(info.FullMethod, peerAddr(ctx))
+		ctx, span := o.tracer().Start(o.extract(ctx), name, trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(attrs...))
+		// handler panic 时同样结束记录，panic 继续向外传递给 recovery 拦截器
+		done := false
+		defer func() {
+			if !done {
+				endSpan(span, errPanic)
+			}
+		}()
+		err := handler(srv, &tracingServerStream{ServerStream: ss, ctx: ctx, messages: &messages{span: span}})
+		done = true
+		endSpan(span, err)
+		return err
+	}
+}
+
+// tracingServerStream 包装 grpc.ServerStream，替换 Context 并记录消息 event
+type tracingServerStream struct {
+	grpc.ServerStream
+	ctx      context.Context
+	messages *messages
+}
+
+func (s *tracingServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *tracingServerStream) SendMsg(m interface{}) error {
+	err := s.ServerStream.SendMsg(m)
+	if err == nil {
+		s.messages.sent(m)
+	}
+	return err
+}
+
+func (s *tracingServerStream) RecvMsg(m interface{}) error {
+	err := s.ServerStream.RecvMsg(m)
+	if err == nil {
+		s.messages.received(m)
+	}
+	return err
+}
+
+// UnaryClientInterceptor 客户端拦截器 - 创建 Client span，并通过 metadata 传递给服务端
+func UnaryClientInterceptor(opts ...Option) grpc.UnaryClientInterceptor {
+	o := newOptions(opts)
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		name, attrs := spanInfo(method, cc.Target())
+		ctx, span := o.tracer().Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+		err := invoker(o.inject(ctx), method, req, reply, cc, opts...)
+		endSpan(span, err)
+		return err
+	}
+}
+
+// StreamClientInterceptor 客户端流拦截器 - 创建 Client span，每条消息记录为 event
+//
+// span 在接收到流结束或错误时结束，调用方没有读取到流结束时 span 不会导出。
+func StreamClientInterceptor(opts ...Option) grpc.StreamClientInterceptor {
+	o := newOptions(opts)
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		name, attrs := spanInfo(method, cc.Target())
+		ctx, span := o.tracer().Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+		cs, err := streamer(o.inject(ctx), desc, cc, method, opts...)
+		if err != nil {
+			endSpan(span, err)
+			return nil, err
+		}
+		return &tracingClientStream{ClientStream: cs, desc: desc, messages: &messages{span: span}}, nil
+	}
+}
+
+// tracingClientStream 包装 grpc.ClientStream，记录消息 event
+type tracingClientStream struct {
+	grpc.ClientStream
+	desc     *grpc.StreamDesc
+	messages *messages
+	once     sync.Once
+}
+
+func (s *tracingClientStream) end(err error) {
+	s.once.Do(func() {
+		endSpan(s.messages.span, err)
+	})
+}
+
+func (s *tracingClientStream) SendMsg(m interface{}) error {
+	err := s.ClientStream.SendMsg(m)
+	if err == nil {
+		s.messages.sent(m)
+	} else if err != io.EOF {
+		s.end(err)
+	}
+	return err
+}
+
+func (s *tracingClientStream) RecvMsg(m interface{}) error {
+	err := s.ClientStream.RecvMsg(m)
+	switch {
+	case err == io.EOF:
+		s.end(nil)
+	case err != nil:
+		s.end(err)
+	default:
+		s.messages.received(m)
+		// 服务端非流式响应只有一条消息，收到后调用结束
+		if !s.desc.ServerStreams {
+			s.end(nil)
+		}
+	}
+	return err
+}
+
+// Middleware 服务端和客户端追踪拦截器，用于 middleware.Registry
+func Middleware(opts ...Option) middleware.Middleware {
+	return middleware.Middleware{
+		UnaryServer:  UnaryServerInterceptor(opts...),
+		StreamServer: StreamServerInterceptor(opts...),
+		UnaryClient:  UnaryClientInterceptor(opts...),
+		StreamClient: StreamClientInterceptor(opts...),
+	}
+}
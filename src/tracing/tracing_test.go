@@ -0,0 +1,208 @@
+package tracing
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/jergoo/go-grpc-tutorial/pingpong"
+	"github.com/jergoo/go-grpc-tutorial/pingtest"
+	pb "github.com/jergoo/go-grpc-tutorial/protos/ping" // 引入编译生成的包
+)
+
+func newTestServer(t *testing.T, impl pb.PingPongServer, client bool) (*pingtest.Server, *tracetest.InMemoryExporter) {
+	tp, exporter := NewInMemory()
+	t.Cleanup(func() { tp.Shutdown(context.Background()) })
+	opts := []pingtest.Option{pingtest.WithServerOptions(
+		grpc.UnaryInterceptor(UnaryServerInterceptor(WithTracerProvider(tp))),
+		grpc.StreamInterceptor(StreamServerInterceptor(WithTracerProvider(tp))),
+	)}
+	if client {
+		opts = append(opts, pingtest.WithDialOptions(
+			grpc.WithUnaryInterceptor(UnaryClientInterceptor(WithTracerProvider(tp))),
+			grpc.WithStreamInterceptor(StreamClientInterceptor(WithTracerProvider(tp))),
+		))
+	}
+	return pingtest.NewServer(t, impl, opts...), exporter
+}
+
+// spanByKind 按类型查找 span
+func spanByKind(spans tracetest.SpanStubs, kind trace.SpanKind) *tracetest.SpanStub {
+	for i := range spans {
+		if spans[i].SpanKind == kind {
+			return &spans[i]
+		}
+	}
+	return nil
+}
+
+// countEvents 统计消息 event 数量
+func countEvents(span *tracetest.SpanStub, typ string) int {
+	n := 0
+	for _, e := range span.Events {
+		for _, a := range e.Attributes {
+			if a.Key == MessageTypeKey && a.Value.AsString() == typ {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+func TestTracing(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name       string
+		call       func(client pb.PingPongClient) error
+		serverRecv int
+		serverSent int
+	}{
+		{
+			name: "protos.PingPong/Ping",
+			call: func(client pb.PingPongClient) error {
+				_, err := client.Ping(ctx, &pb.PingRequest{Value: "ping"})
+				return err
+			},
+		},
+		{
+			name: "protos.PingPong/MultiPong",
+			call: func(client pb.PingPongClient) error {
+				stream, err := client.MultiPong(ctx, &pb.PingRequest{Value: "ping"})
+				if err != nil {
+					return err
+				}
+				for {
+					if _, err := stream.Recv(); err == io.EOF {
+						return nil
+					} else if err != nil {
+						return err
+					}
+				}
+			},
+			serverRecv: 1, serverSent: 3,
+		},
+		{
+			name: "protos.PingPong/MultiPingPong",
+			call: func(client pb.PingPongClient) error {
+				stream, err := client.MultiPingPong(ctx)
+				if err != nil {
+					return err
+				}
+				for i := 0; i < 4; i++ {
+					if err := stream.Send(&pb.PingRequest{Value: "ping"}); err != nil {
+						return err
+					}
+				}
+				stream.CloseSend()
+				for {
+					if _, err := stream.Recv(); err == io.EOF {
+						return nil
+					} else if err != nil {
+						return err
+					}
+				}
+			},
+			serverRecv: 4, serverSent: 2,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			srv, exporter := newTestServer(t, pingpong.NewPingPongServer(pingpong.WithPongCount(3)), true)
+			if err := tt.call(srv.Client); err != nil {
+				t.Fatal(err)
+			}
+			spans := exporter.GetSpans()
+			client, server := spanByKind(spans, trace.SpanKindClient), spanByKind(spans, trace.SpanKindServer)
+			if client == nil || server == nil {
+				t.Fatalf("spans = %d", len(spans))
+			}
+			if client.Name != tt.name || server.Name != tt.name {
+				t.Errorf("name client = %s, server = %s", client.Name, server.Name)
+			}
+			// 服务端 span 的上级为客户端 span
+			if server.SpanContext.TraceID() != client.SpanContext.TraceID() || server.Parent.SpanID() != client.SpanContext.SpanID() {
+				t.Errorf("server span not child of client span")
+			}
+			if !server.Parent.IsRemote() {
+				t.Error("server parent not remote")
+			}
+			if got := countEvents(server, messageTypeReceived); got != tt.serverRecv {
+				t.Errorf("server received events = %d, want %d", got, tt.serverRecv)
+			}
+			if got := countEvents(server, messageTypeSent); got != tt.serverSent {
+				t.Errorf("server sent events = %d, want %d", got, tt.serverSent)
+			}
+			if got := countEvents(client, messageTypeSent); got != tt.serverRecv {
+				t.Errorf("client sent events = %d, want %d", got, tt.serverRecv)
+			}
+			if got := countEvents(client, messageTypeReceived); got != tt.serverSent {
+				t.Errorf("client received events = %d, want %d", got, tt.serverSent)
+			}
+		})
+	}
+}
+
+func TestTraceparent(t *testing.T) {
+	const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	tests := []struct {
+		name    string
+		md      metadata.MD
+		traceID string
+		remote  bool
+	}{
+		{name: "propagated", md: metadata.Pairs("traceparent", traceparent), traceID: "4bf92f3577b34da6a3ce929d0e0e4736", remote: true},
+		{name: "invalid", md: metadata.Pairs("traceparent", "invalid")},
+		{name: "missing"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, exporter := newTestServer(t, pingpong.NewPingPongServer(), false)
+			ctx := metadata.NewOutgoingContext(context.Background(), tt.md)
+			if _, err := srv.Client.Ping(ctx, &pb.PingRequest{Value: "ping"}); err != nil {
+				t.Fatal(err)
+			}
+			server := spanByKind(exporter.GetSpans(), trace.SpanKindServer)
+			if server == nil {
+				t.Fatal("server span missing")
+			}
+			if tt.traceID != "" && server.SpanContext.TraceID().String() != tt.traceID {
+				t.Errorf("trace id = %s, want %s", server.SpanContext.TraceID(), tt.traceID)
+			}
+			if server.Parent.IsRemote() != tt.remote {
+				t.Errorf("remote parent = %t, want %t", server.Parent.IsRemote(), tt.remote)
+			}
+		})
+	}
+}
+
+func TestErrorStatus(t *testing.T) {
+	srv, exporter := newTestServer(t, &pb.UnimplementedPingPongServer{}, true)
+	srv.Client.Ping(context.Background(), &pb.PingRequest{Value: "ping"})
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("spans = %d, want 2", len(spans))
+	}
+	for _, span := range spans {
+		if span.Status.Code != otelcodes.Error {
+			t.Errorf("%s status = %v", span.SpanKind, span.Status.Code)
+		}
+		found := false
+		for _, a := range span.Attributes {
+			if a.Key == RPCStatusCodeKey && a.Value.AsInt64() == 12 {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("%s status code attribute missing: %v", span.SpanKind, span.Attributes)
+		}
+	}
+}
@@ -0,0 +1,283 @@
+// Package validator 根据 proto 文件中声明的字段规则校验请求
+//
+// 规则通过 protos/validate/validate.proto 定义的字段选项声明：
+//
+//	string value = 1 [(validate.rules) = {required: true, max_len: 64}];
+//
+// 拦截器使用 protoreflect 读取消息的字段规则，嵌套的消息逐层校验，
+// 校验失败返回 codes.InvalidArgument，并通过 errdetails.BadRequest 返回每个字段的错误。
+package validator
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"sync"
+	"unicode/utf8"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/jergoo/go-grpc-tutorial/middleware"
+	"github.com/jergoo/go-grpc-tutorial/protos/validate"
+	"github.com/jergoo/go-grpc-tutorial/rpcerr"
+)
+
+// patterns 已编译的正则表达式，按规则中的字符串缓存
+var patterns sync.Map
+
+func compile(pattern string) (*regexp.Regexp, error) {
+	if re, ok := patterns.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	patterns.Store(pattern, re)
+	return re, nil
+}
+
+// Violation 字段错误，Field 为字段路径，如 embMsg.value、intArr[1]
+type Violation struct {
+	Field       string
+	Description string
+}
+
+// Error 校验失败的字段
+type Error []Violation
+
+func (e Error) Error() string {
+	if len(e) == 0 {
+		return "validation failed"
+	}
+	msg := fmt.Sprintf("invalid %s: %s", e[0].Field, e[0].Description)
+	if len(e) > 1 {
+		msg += fmt.Sprintf(" (and %d more)", len(e)-1)
+	}
+	return msg
+}
+
+// GRPCStatus 转换为 codes.InvalidArgument，字段错误保存在 errdetails.BadRequest 中
+func (e Error) GRPCStatus() *status.Status {
+	b := rpcerr.New(codes.InvalidArgument, e.Error()).WithReason(rpcerr.ReasonInvalidRequest, nil)
+	for _, v := range e {
+		b = b.WithFieldViolation(v.Field, v.Description)
+	}
+	return b.Status()
+}
+
+// Validate 校验消息，返回全部字段错误，校验通过返回 nil
+func Validate(m proto.Message) error {
+	var errs Error
+	validateMessage(m.ProtoReflect(), "", &errs)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// FieldViolations 读取错误中的字段错误，用于客户端
+func FieldViolations(err error) []*errdetails.BadRequest_FieldViolation {
+	var out []*errdetails.BadRequest_FieldViolation
+	for _, d := range status.Convert(err).Details() {
+		if br, ok := d.(*errdetails.BadRequest); ok {
+			out = append(out, br.FieldViolations...)
+		}
+	}
+	return out
+}
+
+func validateMessage(m protoreflect.Message, prefix string, errs *Error) {
+	fields := m.Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		path := prefix + string(fd.Name())
+		if rules, ok := proto.GetExtension(fd.Options(), validate.E_Rules).(*validate.FieldRules); ok && rules != nil {
+			validateField(m, fd, rules, path, errs)
+		}
+		// 嵌套的消息逐层校验
+		if fd.Message() == nil || !m.Has(fd) {
+			continue
+		}
+		switch {
+		case fd.IsList():
+			list := m.Get(fd).List()
+			for j := 0; j < list.Len(); j++ {
+				validateMessage(list.Get(j).Message(), path+"["+strconv.Itoa(j)+"].", errs)
+			}
+		case fd.IsMap():
+			if fd.MapValue().Message() != nil {
+				m.Get(fd).Map().Range(func(k protoreflect.MapKey, v protoreflect.Value) bool {
+					validateMessage(v.Message(), path+"["+k.String()+"].", errs)
+					return true
+				})
+			}
+		default:
+			validateMessage(m.Get(fd).Message(), path+".", errs)
+		}
+	}
+}
+
+// validateField 校验单个字段
+func validateField(m protoreflect.Message, fd protoreflect.FieldDescriptor, rules *validate.FieldRules, path string, errs *Error) {
+	add := func(path, format string, args ...interface{}) {
+		*errs = append(*errs, Violation{Field: path, Description: fmt.Sprintf(format, args...)})
+	}
+
+	if fd.IsList() || fd.IsMap() {
+		if rules.Required && !m.Has(fd) {
+			add(path, "must not be empty")
+		}
+		if fd.IsList() {
+			list := m.Get(fd).List()
+			for i := 0; i < list.Len(); i++ {
+				for _, desc := range validateValue(fd, list.Get(i), rules) {
+					add(path+"["+strconv.Itoa(i)+"]", "%s", desc)
+				}
+			}
+		}
+		return
+	}
+
+	if !m.Has(fd) {
+		if rules.Required {
+			add(path, "is required")
+			return
+		}
+		// 未设置的 optional 字段和消息不检查其他规则
+		if fd.HasPresence() {
+			return
+		}
+	}
+	for _, desc := range validateValue(fd, m.Get(fd), rules) {
+		add(path, "%s", desc)
+	}
+}
+
+// validateValue 校验标量值，返回错误描述
+func validateValue(fd protoreflect.FieldDescriptor, v protoreflect.Value, rules *validate.FieldRules) []string {
+	var out []string
+	switch fd.Kind() {
+	case protoreflect.StringKind:
+		out = append(out, checkLen(uint64(utf8.RuneCountInString(v.String())), rules, "characters")...)
+		if rules.Pattern != "" {
+			re, err := compile(rules.Pattern)
+			if err != nil {
+				out = append(out, fmt.Sprintf("invalid pattern %q: %v", rules.Pattern, err))
+			} else if !re.MatchString(v.String()) {
+				out = append(out, fmt.Sprintf("must match pattern %q", rules.Pattern))
+			}
+		}
+	case protoreflect.BytesKind:
+		out = append(out, checkLen(uint64(len(v.Bytes())), rules, "bytes")...)
+	case protoreflect.EnumKind:
+		if rules.DefinedOnly && fd.Enum().Values().ByNumber(v.Enum()) == nil {
+			out = append(out, fmt.Sprintf("must be a defined %s value, got %d", fd.Enum().Name(), v.Enum()))
+		}
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+		protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		out = append(out, checkRange(float64(v.Int()), rules)...)
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind, protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		out = append(out, checkRange(float64(v.Uint()), rules)...)
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		out = append(out, checkRange(v.Float(), rules)...)
+	case protoreflect.MessageKind:
+		if fd.Message().FullName() == "google.protobuf.Duration" {
+			out = append(out, checkDuration(v.Message(), rules)...)
+		}
+	}
+	return out
+}
+
+func checkLen(n uint64, rules *validate.FieldRules, unit string) []string {
+	var out []string
+	if rules.MinLen != nil && n < rules.GetMinLen() {
+		out = append(out, fmt.Sprintf("must be at least %d %s, got %d", rules.GetMinLen(), unit, n))
+	}
+	if rules.MaxLen != nil && n > rules.GetMaxLen() {
+		out = append(out, fmt.Sprintf("must be at most %d %s, got %d", rules.GetMaxLen(), unit, n))
+	}
+	return out
+}
+
+func checkRange(f float64, rules *validate.FieldRules) []string {
+	var out []string
+	if rules.Gte != nil && f < rules.GetGte() {
+		out = append(out, fmt.Sprintf("must be >= %v, got %v", rules.GetGte(), f))
+	}
+	if rules.Lte != nil && f > rules.GetLte() {
+		out = append(out, fmt.Sprintf("must be <= %v, got %v", rules.GetLte(), f))
+	}
+	return out
+}
+
+// checkDuration 校验 google.protobuf.Duration 的取值和范围
+func checkDuration(m protoreflect.Message, rules *validate.FieldRules) []string {
+	fields := m.Descriptor().Fields()
+	d := &durationpb.Duration{
+		Seconds: m.Get(fields.ByName("seconds")).Int(),
+		Nanos:   int32(m.Get(fields.ByName("nanos")).Int()),
+	}
+	if !d.IsValid() {
+		return []string{"must be a valid duration"}
+	}
+	var out []string
+	if rules.DurationGte != nil && d.AsDuration() < rules.DurationGte.AsDuration() {
+		out = append(out, fmt.Sprintf("must be >= %s, got %s", rules.DurationGte.AsDuration(), d.AsDuration()))
+	}
+	if rules.DurationLte != nil && d.AsDuration() > rules.DurationLte.AsDuration() {
+		out = append(out, fmt.Sprintf("must be <= %s, got %s", rules.DurationLte.AsDuration(), d.AsDuration()))
+	}
+	return out
+}
+
+// UnaryServerInterceptor 服务端拦截器 - 校验请求，失败返回 codes.InvalidArgument
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if m, ok := req.(proto.Message); ok {
+			if err := Validate(m); err != nil {
+				return nil, err
+			}
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor 服务端流拦截器 - 校验接收的每条消息
+//
+// 校验失败时 RecvMsg 返回 codes.InvalidArgument，服务方法返回该错误后流结束。
+func StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &validatingServerStream{ss})
+	}
+}
+
+// validatingServerStream 包装 grpc.ServerStream，校验接收的消息
+type validatingServerStream struct {
+	grpc.ServerStream
+}
+
+func (s *validatingServerStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	if pm, ok := m.(proto.Message); ok {
+		return Validate(pm)
+	}
+	return nil
+}
+
+// Middleware 服务端校验拦截器，用于 middleware.Registry
+func Middleware() middleware.Middleware {
+	return middleware.Middleware{
+		UnaryServer:  UnaryServerInterceptor(),
+		StreamServer: StreamServerInterceptor(),
+	}
+}
@@ -0,0 +1,135 @@
+package validator
+
+import (
+	"context"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/jergoo/go-grpc-tutorial/pingpong"
+	"github.com/jergoo/go-grpc-tutorial/pingtest"
+	"github.com/jergoo/go-grpc-tutorial/protos/example"
+	pb "github.com/jergoo/go-grpc-tutorial/protos/ping" // 引入编译生成的包
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		msg    proto.Message
+		fields []string
+	}{
+		{name: "ping ok", msg: &pb.PingRequest{Value: "ping"}},
+		{name: "ping empty", msg: &pb.PingRequest{}, fields: []string{"value"}},
+		{name: "ping too long", msg: &pb.PingRequest{Value: strings.Repeat("a", 65)}, fields: []string{"value"}},
+		{name: "ping multibyte", msg: &pb.PingRequest{Value: strings.Repeat("乒", 64)}},
+		{name: "request ok", msg: &example.Request{Value: proto.String("hello world")}},
+		{name: "request unset", msg: &example.Request{}, fields: []string{"value"}},
+		{name: "request pattern", msg: &example.Request{Value: proto.String("a\nb")}, fields: []string{"value"}},
+		{name: "msg zero", msg: &example.Msg{}},
+		{
+			name: "msg ok",
+			msg:  &example.Msg{I32: 1000, F64: -1, Status: example.Status_FAIL, EmbMsg: &example.EmbMsg{Value: "emb"}, IntArr: []int64{0, 1}},
+		},
+		{
+			name:   "msg invalid",
+			msg:    &example.Msg{I32: -1, F64: 1.5, Status: example.Status(7), EmbMsg: &example.EmbMsg{Value: strings.Repeat("a", 33)}, IntArr: []int64{1, -1}},
+			fields: []string{"i32", "f64", "status", "embMsg.value", "intArr[1]"},
+		},
+		{name: "duration ok", msg: &pb.StreamOptions{ReplyInterval: durationpb.New(time.Minute)}},
+		{name: "duration too long", msg: &pb.StreamOptions{ReplyInterval: durationpb.New(time.Minute + 1)}, fields: []string{"reply_interval"}},
+		{name: "duration negative", msg: &pb.StreamOptions{ReplyInterval: durationpb.New(-time.Second)}, fields: []string{"reply_interval"}},
+		{name: "duration invalid", msg: &pb.StreamOptions{ReplyInterval: &durationpb.Duration{Seconds: 1, Nanos: -1}}, fields: []string{"reply_interval"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.msg)
+			var fields []string
+			for _, v := range FieldViolations(err) {
+				fields = append(fields, v.Field)
+			}
+			if !reflect.DeepEqual(fields, tt.fields) {
+				t.Errorf("fields = %v, want %v (%v)", fields, tt.fields, err)
+			}
+			if tt.fields != nil && status.Code(err) != codes.InvalidArgument {
+				t.Errorf("code = %s", status.Code(err))
+			}
+		})
+	}
+}
+
+func TestInterceptor(t *testing.T) {
+	srv := pingtest.NewServer(t, pingpong.NewPingPongServer(pingpong.WithBatchSize(1)), pingtest.WithServerOptions(
+		grpc.UnaryInterceptor(UnaryServerInterceptor()),
+		grpc.StreamInterceptor(StreamServerInterceptor()),
+	))
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		call func() error
+	}{
+		{
+			name: "unary",
+			call: func() error {
+				_, err := srv.Client.Ping(ctx, &pb.PingRequest{})
+				return err
+			},
+		},
+		{
+			name: "server stream",
+			call: func() error {
+				stream, err := srv.Client.MultiPong(ctx, &pb.PingRequest{})
+				if err != nil {
+					return err
+				}
+				_, err = stream.Recv()
+				return err
+			},
+		},
+		{
+			// 第二条消息校验失败，流结束
+			name: "bidi stream",
+			call: func() error {
+				stream, err := srv.Client.MultiPingPong(ctx)
+				if err != nil {
+					return err
+				}
+				stream.Send(&pb.PingRequest{Value: "ping"})
+				stream.Send(&pb.PingRequest{Value: strings.Repeat("a", 100)})
+				stream.CloseSend()
+				for {
+					if _, err := stream.Recv(); err != nil {
+						if err == io.EOF {
+							return nil
+						}
+						return err
+					}
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.call()
+			if status.Code(err) != codes.InvalidArgument {
+				t.Fatalf("err = %v", err)
+			}
+			violations := FieldViolations(err)
+			if len(violations) != 1 || violations[0].Field != "value" {
+				t.Errorf("violations = %v", violations)
+			}
+		})
+	}
+
+	if _, err := srv.Client.Ping(ctx, &pb.PingRequest{Value: "ping"}); err != nil {
+		t.Error(err)
+	}
+}